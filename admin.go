@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/feeds"
+)
+
+// runCommand runs an admin subcommand and returns the exit status for the process.
+func runCommand(args []string, feedsHandler feeds.Handler) int {
+	if len(args) < 3 || args[0] != "opml" {
+		fmt.Fprintln(os.Stderr, "usage: distinguished_taste_society_bots opml import <group_id> <file>")
+		fmt.Fprintln(os.Stderr, "       distinguished_taste_society_bots opml export <group_id> [file]")
+		return 2
+	}
+	groupID := args[2]
+	switch args[1] {
+	case "import":
+		if len(args) < 4 {
+			fmt.Fprintln(os.Stderr, "opml import needs a file to read")
+			return 2
+		}
+		f, err := os.Open(args[3])
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		defer f.Close()
+		added, skipped, err := feedsHandler.ImportOPML(groupID, f)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+		fmt.Printf("Imported %d feeds, skipped %d.\n", added, skipped)
+	case "export":
+		var out io.Writer = os.Stdout
+		if len(args) > 3 {
+			f, err := os.Create(args[3])
+			if err != nil {
+				fmt.Fprintln(os.Stderr, err)
+				return 1
+			}
+			defer f.Close()
+			out = f
+		}
+		err := feeds.ExportOPML(groupID, out)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 1
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "unknown opml subcommand", args[1])
+		return 2
+	}
+	return 0
+}
+
+// serveAdmin listens for admin requests on addr. Requests must carry token as a bearer token.
+func serveAdmin(addr, token string, feedsHandler feeds.Handler) {
+	mux := http.NewServeMux()
+	mux.Handle("/admin/feeds/opml", feedsHandler.AdminHandler(token))
+	fmt.Println("Admin handler listening on", addr)
+	err := http.ListenAndServe(addr, mux)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,158 @@
+package groupme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// DefaultAPIURL is the root of the GroupMe API.
+const DefaultAPIURL = "https://api.groupme.com/v3"
+
+type (
+	// CalendarService creates events on GroupMe group calendars, which can then be shared in a post with an "event"
+	// attachment.
+	CalendarService struct {
+		// URL is the root of the API. DefaultAPIURL is used when it's blank.
+		URL string
+		// Token is the GroupMe access token sent with each request. Its user must be a member of the group.
+		Token string
+		// Client is the HTTP client used for requests. A client with a 30 second timeout is used when nil.
+		Client *http.Client
+	}
+
+	// CalendarEvent is an event to add to a group's calendar.
+	CalendarEvent struct {
+		Name        string
+		Description string
+		Start       time.Time
+		// End defaults to an hour after Start
+		End    time.Time
+		AllDay bool
+		// Timezone is the IANA name of the zone the event is shown in; the zone of Start is used when it's blank
+		Timezone string
+		// Location names the venue; Address and coordinates are optional
+		Location  string
+		Address   string
+		Latitude  float64
+		Longitude float64
+	}
+
+	// eventRequest is the body of a request to create a calendar event.
+	eventRequest struct {
+		Name        string         `json:"name"`
+		Description string         `json:"description,omitempty"`
+		StartAt     string         `json:"start_at"`
+		EndAt       string         `json:"end_at"`
+		IsAllDay    bool           `json:"is_all_day"`
+		Timezone    string         `json:"timezone"`
+		Location    *eventLocation `json:"location,omitempty"`
+	}
+
+	// eventLocation is where a calendar event takes place.
+	eventLocation struct {
+		Name    string `json:"name"`
+		Address string `json:"address,omitempty"`
+		Lat     string `json:"lat,omitempty"`
+		Lng     string `json:"lng,omitempty"`
+	}
+
+	// eventResponse is the JSON returned after creating a calendar event.
+	eventResponse struct {
+		Response struct {
+			Event struct {
+				EventID string `json:"event_id"`
+			} `json:"event"`
+		} `json:"response"`
+	}
+)
+
+// NewCalendarService sets up a CalendarService from the GROUPME_API_URL and GROUPME_ACCESS_TOKEN environment variables.
+func NewCalendarService() CalendarService {
+	return CalendarService{URL: os.Getenv("GROUPME_API_URL"), Token: os.Getenv("GROUPME_ACCESS_TOKEN")}
+}
+
+// Enabled reports whether the service has the token it needs to create anything.
+func (s CalendarService) Enabled() bool {
+	return s.Token != ""
+}
+
+func (s CalendarService) client() *http.Client {
+	if s.Client == nil {
+		return &http.Client{Timeout: 30 * time.Second}
+	}
+	return s.Client
+}
+
+// CreateEvent adds an event to a group's calendar, returning the event ID to use in an event attachment.
+func (s CalendarService) CreateEvent(groupID string, event CalendarEvent) (string, error) {
+	if !s.Enabled() {
+		return "", errors.New("No GroupMe access token provided")
+	}
+	if event.Name == "" || event.Start.IsZero() {
+		return "", errors.New("Calendar events need a name and a start time")
+	}
+	end := event.End
+	if !end.After(event.Start) {
+		end = event.Start.Add(time.Hour)
+	}
+	zone := event.Timezone
+	if zone == "" {
+		zone = event.Start.Location().String()
+	}
+	if zone == "Local" {
+		zone = "UTC"
+	}
+	body := eventRequest{
+		Name:        event.Name,
+		Description: event.Description,
+		StartAt:     event.Start.Format(time.RFC3339),
+		EndAt:       end.Format(time.RFC3339),
+		IsAllDay:    event.AllDay,
+		Timezone:    zone,
+	}
+	if event.Location != "" {
+		body.Location = &eventLocation{Name: event.Location, Address: event.Address}
+		if event.Latitude != 0 || event.Longitude != 0 {
+			body.Location.Lat = fmt.Sprint(event.Latitude)
+			body.Location.Lng = fmt.Sprint(event.Longitude)
+		}
+	}
+	data, err := json.Marshal(body)
+	if err != nil {
+		return "", err
+	}
+	root := s.URL
+	if root == "" {
+		root = DefaultAPIURL
+	}
+	endpoint := strings.TrimRight(root, "/") + "/conversations/" + url.PathEscape(groupID) + "/events/create"
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Access-Token", s.Token)
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return "", fmt.Errorf("HTTP Response Error %d creating calendar event", resp.StatusCode)
+	}
+	var er eventResponse
+	if err := json.NewDecoder(resp.Body).Decode(&er); err != nil {
+		return "", err
+	}
+	if er.Response.Event.EventID == "" {
+		return "", errors.New("GroupMe didn't return an event ID")
+	}
+	return er.Response.Event.EventID, nil
+}
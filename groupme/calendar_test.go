@@ -0,0 +1,134 @@
+package groupme
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCreateEvent(t *testing.T) {
+	var got eventRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != "POST" {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if r.URL.Path != "/conversations/123/events/create" {
+			t.Errorf("path = %s, want /conversations/123/events/create", r.URL.Path)
+		}
+		if token := r.Header.Get("X-Access-Token"); token != "secret" {
+			t.Errorf("X-Access-Token = %q, want secret", token)
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("decoding body: %v", err)
+		}
+		w.WriteHeader(201)
+		fmt.Fprint(w, `{"response":{"event":{"event_id":"abc"}}}`)
+	}))
+	defer server.Close()
+
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip(err)
+	}
+	s := CalendarService{URL: server.URL + "/", Token: "secret"}
+	id, err := s.CreateEvent("123", CalendarEvent{
+		Name:      "Jazz night",
+		Start:     time.Date(2026, 10, 21, 19, 30, 0, 0, chicago),
+		Location:  "The Ale House",
+		Address:   "1 Main St",
+		Latitude:  41.5,
+		Longitude: -87.25,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id != "abc" {
+		t.Errorf("id = %q, want abc", id)
+	}
+	if got.Name != "Jazz night" {
+		t.Errorf("name = %q", got.Name)
+	}
+	if got.StartAt != "2026-10-21T19:30:00-05:00" || got.EndAt != "2026-10-21T20:30:00-05:00" {
+		t.Errorf("start_at, end_at = %s, %s", got.StartAt, got.EndAt)
+	}
+	if got.IsAllDay {
+		t.Error("is_all_day = true, want false")
+	}
+	if got.Timezone != "America/Chicago" {
+		t.Errorf("timezone = %q, want America/Chicago", got.Timezone)
+	}
+	if got.Location == nil {
+		t.Fatal("no location sent")
+	}
+	if *got.Location != (eventLocation{Name: "The Ale House", Address: "1 Main St", Lat: "41.5", Lng: "-87.25"}) {
+		t.Errorf("location = %+v", *got.Location)
+	}
+}
+
+func TestCreateEventAllDay(t *testing.T) {
+	var got eventRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&got)
+		fmt.Fprint(w, `{"response":{"event":{"event_id":"abc"}}}`)
+	}))
+	defer server.Close()
+
+	s := CalendarService{URL: server.URL, Token: "secret"}
+	_, err := s.CreateEvent("123", CalendarEvent{
+		Name:     "Street fair",
+		Start:    time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC),
+		AllDay:   true,
+		Timezone: "America/New_York",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.IsAllDay || got.Timezone != "America/New_York" {
+		t.Errorf("is_all_day, timezone = %v, %q", got.IsAllDay, got.Timezone)
+	}
+	if got.StartAt != "2026-10-24T00:00:00Z" || got.EndAt != "2026-10-25T00:00:00Z" {
+		t.Errorf("start_at, end_at = %s, %s", got.StartAt, got.EndAt)
+	}
+	if got.Location != nil {
+		t.Errorf("location = %+v, want none", *got.Location)
+	}
+}
+
+func TestCreateEventErrors(t *testing.T) {
+	status, body := 0, ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+		fmt.Fprint(w, body)
+	}))
+	defer server.Close()
+
+	s := CalendarService{URL: server.URL, Token: "secret"}
+	event := CalendarEvent{Name: "Jazz night", Start: time.Date(2026, 10, 21, 19, 30, 0, 0, time.UTC)}
+	tests := []struct {
+		status int
+		body   string
+	}{
+		{401, `{"meta":{"code":401,"errors":["unauthorized"]}}`},
+		{404, `{"meta":{"code":404}}`},
+		{500, ``},
+		{200, `not json`},
+		{200, `{"response":{"event":{}}}`},
+	}
+	for _, test := range tests {
+		status, body = test.status, test.body
+		if id, err := s.CreateEvent("123", event); err == nil {
+			t.Errorf("%d %s: got event %q, want an error", test.status, test.body, id)
+		}
+	}
+
+	if _, err := (CalendarService{URL: server.URL}).CreateEvent("123", event); err == nil {
+		t.Error("no token: want an error")
+	}
+	if _, err := s.CreateEvent("123", CalendarEvent{Name: "Jazz night"}); err == nil {
+		t.Error("no start: want an error")
+	}
+}
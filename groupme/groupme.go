@@ -0,0 +1,139 @@
+/*
+Package groupme talks to the parts of the GroupMe API that the bot callbacks in github.com/sha1sum/golang_groupme_bot
+don't cover: the image service that GroupMe requires every image attachment to be hosted on, and group calendars.
+
+Requests are made on behalf of a GroupMe user, so an access token (GROUPME_ACCESS_TOKEN) is needed. The service
+addresses can be overridden so that a stand-in server can be used instead of GroupMe's own.
+*/
+package groupme
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+)
+
+// DefaultImageServiceURL is GroupMe's image service endpoint.
+const DefaultImageServiceURL = "https://image.groupme.com/pictures"
+
+// maxImageSize is the largest image that will be copied over to the image service.
+const maxImageSize = 5 * 1024 * 1024
+
+// ImageService uploads images to GroupMe's image service (or a compatible endpoint) so they can be attached to posts.
+type ImageService struct {
+	// URL is the endpoint images are posted to. DefaultImageServiceURL is used when it's blank.
+	URL string
+	// Token is the GroupMe access token sent with each upload
+	Token string
+	// Client is the HTTP client used for downloads and uploads. When it's nil, images are downloaded with a client that
+	// only connects to public addresses, since their links come from elsewhere, and uploaded with a plain one. Both
+	// have a 30 second timeout.
+	Client *http.Client
+}
+
+// imageResponse is the JSON returned by the image service after an upload.
+type imageResponse struct {
+	Payload struct {
+		URL        string `json:"url"`
+		PictureURL string `json:"picture_url"`
+	} `json:"payload"`
+}
+
+// NewImageService sets up an ImageService from the GROUPME_IMAGE_SERVICE_URL and GROUPME_ACCESS_TOKEN environment
+// variables.
+func NewImageService() ImageService {
+	return ImageService{URL: os.Getenv("GROUPME_IMAGE_SERVICE_URL"), Token: os.Getenv("GROUPME_ACCESS_TOKEN")}
+}
+
+// Enabled reports whether the service has the token it needs to upload anything.
+func (s ImageService) Enabled() bool {
+	return s.Token != ""
+}
+
+// imageTimeout is how long the default clients wait for a download or upload.
+const imageTimeout = 30 * time.Second
+
+// downloadClient is the default client for downloading images.
+var downloadClient = matchers.PublicClient(imageTimeout)
+
+func (s ImageService) client() *http.Client {
+	if s.Client == nil {
+		return &http.Client{Timeout: imageTimeout}
+	}
+	return s.Client
+}
+
+// Upload downloads the image at imageURL and re-hosts it on the image service, returning the URL to use in an image
+// attachment.
+func (s ImageService) Upload(imageURL string) (string, error) {
+	if !s.Enabled() {
+		return "", errors.New("No GroupMe access token provided")
+	}
+	client := s.Client
+	if client == nil {
+		client = downloadClient
+	}
+	resp, err := client.Get(imageURL)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return "", fmt.Errorf("HTTP Response Error %d downloading image", resp.StatusCode)
+	}
+	contentType := resp.Header.Get("Content-Type")
+	if !strings.HasPrefix(contentType, "image/") {
+		return "", fmt.Errorf("Not an image: %s", contentType)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxImageSize+1))
+	if err != nil {
+		return "", err
+	}
+	if len(data) > maxImageSize {
+		return "", errors.New("Image is too large to upload")
+	}
+	return s.UploadData(data, contentType)
+}
+
+// UploadData posts raw image data to the image service, returning the URL to use in an image attachment.
+func (s ImageService) UploadData(data []byte, contentType string) (string, error) {
+	endpoint := s.URL
+	if endpoint == "" {
+		endpoint = DefaultImageServiceURL
+	}
+	req, err := http.NewRequest("POST", endpoint, bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Access-Token", s.Token)
+	resp, err := s.client().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 && resp.StatusCode != 201 {
+		return "", fmt.Errorf("HTTP Response Error %d uploading image", resp.StatusCode)
+	}
+	var ir imageResponse
+	err = json.NewDecoder(resp.Body).Decode(&ir)
+	if err != nil {
+		return "", err
+	}
+	if ir.Payload.PictureURL != "" {
+		return ir.Payload.PictureURL, nil
+	}
+	if ir.Payload.URL != "" {
+		return ir.Payload.URL, nil
+	}
+	return "", errors.New("Image service didn't return an image URL")
+}
@@ -0,0 +1,43 @@
+package groupme
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestUploadRefusesPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback image was requested")
+	}))
+	defer server.Close()
+	s := ImageService{URL: server.URL, Token: "secret"}
+	if img, err := s.Upload(server.URL + "/cat.png"); err == nil {
+		t.Errorf("Upload(loopback) = %q, want an error", img)
+	}
+}
+
+func TestUploadData(t *testing.T) {
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if ct := r.Header.Get("Content-Type"); ct != "image/png" {
+			t.Errorf("Content-Type = %q, want image/png", ct)
+		}
+		body, _ = ioutil.ReadAll(r.Body)
+		fmt.Fprint(w, `{"payload":{"url":"https://i.groupme.com/abc","picture_url":"https://i.groupme.com/abc.png"}}`)
+	}))
+	defer server.Close()
+	s := ImageService{URL: server.URL, Token: "secret"}
+	img, err := s.UploadData([]byte("\x89PNG\x00data"), "image/png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img != "https://i.groupme.com/abc.png" {
+		t.Errorf("image = %q, want the picture URL", img)
+	}
+	if string(body) != "\x89PNG\x00data" {
+		t.Errorf("uploaded %q", body)
+	}
+}
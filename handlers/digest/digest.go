@@ -0,0 +1,344 @@
+/*
+Package digest posts a scheduled daily news briefing to a GroupMe group. Each group keeps its own list of topics and a
+local time of day for the digest, managed with "!digest add <topic>", "!digest remove <topic>", "!digest list" and
+"!digest time <time> [timezone]", e.g. "!digest time 7:30am America/New_York". At the scheduled time the top stories for
+every topic are pulled from Google News, duplicates are dropped, and one numbered message is posted.
+
+Like the other bots in this project, the digest settings are kept in MongoDB, located with the MONGOLAB_URI and
+MONGOLAB_DB environment variables.
+*/
+package digest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/googlenews"
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Handler will satisfy the bot.Handler interface.
+type Handler struct {
+	// Stories is the number of top stories taken for each topic
+	Stories int
+	// Timezone is the IANA time zone used for groups that haven't picked their own
+	Timezone string
+}
+
+// groupDigest is the stored digest configuration for a single GroupMe group.
+type groupDigest struct {
+	GroupID string   `bson:"group_id"`
+	Topics  []string `bson:"topics"`
+	// Time is the local time of day to post, formatted as "15:04"
+	Time     string    `bson:"time"`
+	Timezone string    `bson:"timezone"`
+	LastSent time.Time `bson:"last_sent"`
+}
+
+// story is a single entry in a digest.
+type story struct {
+	Title string
+	Link  string
+}
+
+const (
+	collection  = "groupmeDigestsV1"
+	defaultTime = "07:00"
+	// maxLength is the longest message GroupMe will accept
+	maxLength = 1000
+	usage     = "Use \"!digest add <topic>\", \"!digest remove <topic>\", \"!digest list\" or " +
+		"\"!digest time <time> [timezone]\" to set up the daily news digest."
+)
+
+// DB is the name of the MongoDB database
+var DB = handlers.DB
+
+// Handle manages the digest settings for the group the message was posted in.
+func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" {
+		return
+	}
+	args := handlers.Arguments(message.Text, "digest")
+	if len(args) < 1 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: usage}}
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	var text string
+	switch strings.ToLower(args[0]) {
+	case "add":
+		text, err = handler.addTopic(col, message.GroupID, strings.ToLower(strings.Join(args[1:], " ")))
+	case "remove":
+		text, err = removeTopic(col, message.GroupID, strings.ToLower(strings.Join(args[1:], " ")))
+	case "list":
+		text, err = handler.listTopics(col, message.GroupID)
+	case "time":
+		text, err = handler.setTime(col, message.GroupID, args[1:])
+	default:
+		text = usage
+	}
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: text}}
+}
+
+// timezone returns the handler's default time zone name.
+func (handler Handler) timezone() string {
+	if handler.Timezone == "" {
+		return "America/New_York"
+	}
+	return handler.Timezone
+}
+
+// stories returns the number of stories to take for each topic.
+func (handler Handler) stories() int {
+	if handler.Stories < 1 {
+		return 3
+	}
+	return handler.Stories
+}
+
+// find loads the group's digest settings, filling in the defaults for a group that hasn't got any yet.
+func (handler Handler) find(col *mgo.Collection, groupID string) (groupDigest, error) {
+	var d groupDigest
+	err := col.Find(bson.M{"group_id": groupID}).One(&d)
+	if err == mgo.ErrNotFound {
+		return groupDigest{GroupID: groupID, Time: defaultTime, Timezone: handler.timezone()}, nil
+	}
+	return d, err
+}
+
+// addTopic adds a topic to the group's digest, creating the digest if this is the first topic.
+func (handler Handler) addTopic(col *mgo.Collection, groupID, topic string) (string, error) {
+	if topic == "" {
+		return "", errors.New("You need to say which topic to add.")
+	}
+	d, err := handler.find(col, groupID)
+	if err != nil {
+		return "", err
+	}
+	for _, t := range d.Topics {
+		if t == topic {
+			return "\"" + topic + "\" is already in the digest.", nil
+		}
+	}
+	_, err = col.Upsert(bson.M{"group_id": groupID}, bson.M{
+		"$setOnInsert": bson.M{"time": d.Time, "timezone": d.Timezone},
+		"$addToSet":    bson.M{"topics": topic},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "\"" + topic + "\" will be included in the daily digest at " + displayTime(d) + ".", nil
+}
+
+// removeTopic takes a topic out of the group's digest.
+func removeTopic(col *mgo.Collection, groupID, topic string) (string, error) {
+	if topic == "" {
+		return "", errors.New("You need to say which topic to remove.")
+	}
+	err := col.Update(bson.M{"group_id": groupID, "topics": topic}, bson.M{"$pull": bson.M{"topics": topic}})
+	if err == mgo.ErrNotFound {
+		return "\"" + topic + "\" isn't in the digest.", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return "\"" + topic + "\" has been removed from the daily digest.", nil
+}
+
+// listTopics describes the group's digest.
+func (handler Handler) listTopics(col *mgo.Collection, groupID string) (string, error) {
+	d, err := handler.find(col, groupID)
+	if err != nil {
+		return "", err
+	}
+	if len(d.Topics) < 1 {
+		return "The daily digest has no topics yet. Add one with \"!digest add <topic>\".", nil
+	}
+	text := "The daily digest is posted at " + displayTime(d) + " and covers:"
+	for i, t := range d.Topics {
+		text += "\n" + strconv.Itoa(i+1) + ". " + t
+	}
+	return text, nil
+}
+
+// setTime changes when the group's digest is posted, and optionally the time zone it's posted in.
+func (handler Handler) setTime(col *mgo.Collection, groupID string, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", errors.New("You need to give a time, like \"!digest time 7:30am\".")
+	}
+	at, err := parseClock(args[0])
+	if err != nil {
+		return "", err
+	}
+	d, err := handler.find(col, groupID)
+	if err != nil {
+		return "", err
+	}
+	d.Time = at
+	if len(args) > 1 {
+		if _, err := time.LoadLocation(args[1]); err != nil {
+			return "", errors.New("I don't know the time zone \"" + args[1] + "\".")
+		}
+		d.Timezone = args[1]
+	}
+	_, err = col.Upsert(bson.M{"group_id": groupID}, bson.M{
+		"$set": bson.M{"time": d.Time, "timezone": d.Timezone},
+	})
+	if err != nil {
+		return "", err
+	}
+	return "The daily digest will now be posted at " + displayTime(d) + ".", nil
+}
+
+// clockLayouts are the accepted ways of writing a time of day.
+var clockLayouts = []string{"15:04", "3:04pm", "3pm"}
+
+// parseClock reads a time of day and returns it in "15:04" form.
+func parseClock(value string) (string, error) {
+	for _, layout := range clockLayouts {
+		t, err := time.Parse(layout, strings.ToLower(value))
+		if err == nil {
+			return t.Format("15:04"), nil
+		}
+	}
+	return "", errors.New("Couldn't understand the time \"" + value + "\".")
+}
+
+// displayTime formats the digest's scheduled time for a message.
+func displayTime(d groupDigest) string {
+	t, err := time.Parse("15:04", d.Time)
+	if err != nil {
+		return d.Time + " " + d.Timezone
+	}
+	return t.Format("3:04pm") + " " + d.Timezone
+}
+
+// SetupDigest starts checking every minute for whether groupID's digest is due, and posts it using botID. A bot can
+// only post in the group it was added to, so botID has to be that group's.
+func (handler Handler) SetupDigest(botID, groupID string) {
+	ticker := time.NewTicker(time.Minute)
+	go func(handler Handler, botID string) {
+		for range ticker.C {
+			handler.sendDue(botID, groupID, time.Now())
+		}
+	}(handler, botID)
+}
+
+// sendDue posts groupID's digest if its scheduled time has come up since its last one.
+func (handler Handler) sendDue(botID, groupID string, now time.Time) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	var digests []groupDigest
+	err = col.Find(bson.M{"group_id": groupID}).All(&digests)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, d := range digests {
+		scheduled, ok := scheduledFor(d, now)
+		if !ok || len(d.Topics) < 1 {
+			continue
+		}
+		// Only post within an hour of the scheduled time so that a restart late in the day doesn't post a stale digest.
+		if now.Before(scheduled) || now.Sub(scheduled) > time.Hour || !d.LastSent.Before(scheduled) {
+			continue
+		}
+		err = col.Update(bson.M{"group_id": d.GroupID}, bson.M{"$set": bson.M{"last_sent": now}})
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		m := buildDigest(d.Topics, handler.stories(), scheduled)
+		_, err = bot.PostMessage(m, botID)
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// scheduledFor returns the time that the digest is scheduled for on the local day of now.
+func scheduledFor(d groupDigest, now time.Time) (time.Time, bool) {
+	loc, err := time.LoadLocation(d.Timezone)
+	if err != nil {
+		return time.Time{}, false
+	}
+	at, err := time.Parse("15:04", d.Time)
+	if err != nil {
+		return time.Time{}, false
+	}
+	local := now.In(loc)
+	return time.Date(local.Year(), local.Month(), local.Day(), at.Hour(), at.Minute(), 0, 0, loc), true
+}
+
+// buildDigest fetches the top stories for each topic and renders them as one numbered message.
+func buildDigest(topics []string, perTopic int, day time.Time) *bot.OutgoingMessage {
+	text := "Daily digest for " + day.Format("Monday, January 2") + ":"
+	stories := topStories(topics, perTopic)
+	if len(stories) < 1 {
+		return &bot.OutgoingMessage{Text: text + "\nNo stories today."}
+	}
+	for i, s := range stories {
+		line := "\n" + strconv.Itoa(i+1) + ". " + s.Title + " " + s.Link
+		if len(text)+len(line) > maxLength {
+			break
+		}
+		text += line
+	}
+	return &bot.OutgoingMessage{Text: text}
+}
+
+// topStories retrieves up to perTopic stories for each topic, skipping any story that has already been picked for an
+// earlier topic.
+func topStories(topics []string, perTopic int) []story {
+	stories := make([]story, 0)
+	seen := make(map[string]bool)
+	for _, topic := range topics {
+		doc, err := matchers.Retrieve(googlenews.FeedURL(topic))
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		taken := 0
+		for _, item := range doc.Channel.Item {
+			if taken >= perTopic {
+				break
+			}
+			link, err := googlenews.OriginalLink(item)
+			if err != nil {
+				continue
+			}
+			title := strings.TrimSpace(item.Title)
+			key := strings.ToLower(title)
+			if seen[link] || seen[key] {
+				continue
+			}
+			seen[link] = true
+			seen[key] = true
+			stories = append(stories, story{Title: title, Link: link})
+			taken++
+		}
+	}
+	return stories
+}
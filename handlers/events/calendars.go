@@ -0,0 +1,146 @@
+package events
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// calendarCommand handles "!events calendar add <url>", "!events calendar remove <url>" and "!events calendar list",
+// which manage the iCalendar feeds searched alongside the provider for a group.
+func calendarCommand(words []string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	usage := "Use \"!events calendar add <url>\", \"!events calendar remove <url>\" or \"!events calendar list\"."
+	if len(words) < 1 {
+		return &bot.OutgoingMessage{Text: usage}
+	}
+	switch words[0] {
+	case "list":
+		return listCalendars(message.GroupID)
+	case "add", "remove":
+		// URLs are case-sensitive, so the link is taken from the message as sent.
+		args := argsAfter(message.Text, words[0])
+		if len(args) < 1 {
+			return &bot.OutgoingMessage{Text: usage}
+		}
+		if words[0] == "add" {
+			return addCalendar(message.GroupID, args[0])
+		}
+		return removeCalendar(message.GroupID, args[0])
+	}
+	return &bot.OutgoingMessage{Text: usage}
+}
+
+// addCalendar checks that a link is an iCalendar feed and saves it as one of a group's calendars.
+func addCalendar(groupID, link string) *bot.OutgoingMessage {
+	link = strings.Replace(link, "webcal://", "https://", 1)
+	if u, err := url.Parse(link); err != nil || u.Host == "" || u.Scheme != "http" && u.Scheme != "https" {
+		return &bot.OutgoingMessage{Text: "\"" + link + "\" doesn't look like a link to a calendar."}
+	}
+	cal, err := matchers.RetrieveCalendar(link)
+	if err != nil {
+		return &bot.OutgoingMessage{Text: "Couldn't read a calendar from " + link + ": " + err.Error()}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	_, err = sess.DB(DB).C(groupsCollection).Upsert(bson.M{"group_id": groupID}, bson.M{
+		"$addToSet": bson.M{"calendars": link},
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	name := cal.Name
+	if name == "" {
+		name = link
+	}
+	return &bot.OutgoingMessage{Text: fmt.Sprintf("Event searches here will now include %s (%d events).", name, len(cal.Events))}
+}
+
+// removeCalendar drops one of a group's calendars.
+func removeCalendar(groupID, link string) *bot.OutgoingMessage {
+	link = strings.Replace(link, "webcal://", "https://", 1)
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	settings, err := findGroup(sess, groupID)
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	found := false
+	for _, v := range settings.Calendars {
+		found = found || v == link
+	}
+	if !found {
+		return &bot.OutgoingMessage{Text: link + " isn't one of this group's calendars. See them with \"!events calendar list\"."}
+	}
+	err = sess.DB(DB).C(groupsCollection).Update(bson.M{"group_id": groupID}, bson.M{
+		"$pull": bson.M{"calendars": link},
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "Event searches here will no longer include " + link + "."}
+}
+
+// listCalendars lists a group's calendars.
+func listCalendars(groupID string) *bot.OutgoingMessage {
+	calendars := groupCalendars(groupID)
+	if len(calendars) == 0 {
+		return &bot.OutgoingMessage{Text: "No calendars are searched here. Add one with \"!events calendar add <url>\"."}
+	}
+	lines := make([]string, len(calendars))
+	for i, link := range calendars {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, link)
+	}
+	return &bot.OutgoingMessage{Text: "Calendars searched here:\n" + strings.Join(lines, "\n")}
+}
+
+// groupCalendars returns the links to a group's calendars.
+func groupCalendars(groupID string) []string {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return nil
+	}
+	defer sess.Close()
+	settings, err := findGroup(sess, groupID)
+	if err != nil {
+		fmt.Println(err)
+	}
+	return settings.Calendars
+}
+
+// calendarEvents searches calendars, skipping any that can't be read.
+func calendarEvents(links []string, req SearchRequest) []Event {
+	var events []Event
+	for _, link := range links {
+		found, err := ICSProvider{URL: link}.SearchAll(req, 0)
+		if err != nil {
+			fmt.Println(err)
+			continue
+		}
+		events = append(events, found...)
+	}
+	return events
+}
+
+// argsAfter returns the words of a message that follow the first one equal to keyword, ignoring case, as they were
+// sent.
+func argsAfter(text, keyword string) []string {
+	words := strings.Fields(text)
+	for i, word := range words {
+		if strings.EqualFold(word, keyword) {
+			return words[i+1:]
+		}
+	}
+	return nil
+}
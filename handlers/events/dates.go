@@ -0,0 +1,248 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// window is the span of time an event search covers.
+type window struct {
+	Start time.Time
+	End   time.Time
+	// Label describes the window for replies, e.g. "this weekend (Fri Oct 23 - Sun Oct 25)"
+	Label string
+}
+
+// months maps month names and their abbreviations to months.
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January,
+	"february": time.February, "feb": time.February,
+	"march": time.March, "mar": time.March,
+	"april": time.April, "apr": time.April,
+	"may":  time.May,
+	"june": time.June, "jun": time.June,
+	"july": time.July, "jul": time.July,
+	"august": time.August, "aug": time.August,
+	"september": time.September, "sep": time.September, "sept": time.September,
+	"october": time.October, "oct": time.October,
+	"november": time.November, "nov": time.November,
+	"december": time.December, "dec": time.December,
+}
+
+// datePattern matches explicit dates and ranges like "10/20", "10/20/2026" and "10/20-10/25".
+var datePattern = regexp.MustCompile(`^(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?(?:-(\d{1,2})/(\d{1,2})(?:/(\d{2}|\d{4}))?)?$`)
+
+// whenPhrase checks whether the words starting at words[0] name a date range, returning the phrase and the number of
+// words it takes up.
+func whenPhrase(words []string) (string, int) {
+	switch words[0] {
+	case "tonight", "today", "tomorrow":
+		return words[0], 1
+	case "this", "next":
+		if len(words) > 1 {
+			switch words[1] {
+			case "weekend", "week", "month":
+				return words[0] + " " + words[1], 2
+			}
+		}
+	case "in":
+		if len(words) > 1 {
+			if _, ok := months[words[1]]; ok {
+				return words[0] + " " + words[1], 2
+			}
+		}
+	}
+	if datePattern.MatchString(words[0]) {
+		return words[0], 1
+	}
+	return "", 0
+}
+
+// resolveWindow turns a date phrase into the span of time it means, in the time zone of now. With no phrase, the
+// window runs from now for the given number of days.
+func resolveWindow(when string, now time.Time, days int) (window, error) {
+	today := midnight(now)
+	switch when {
+	case "":
+		end := today.AddDate(0, 0, days+1)
+		return window{Start: now, End: end, Label: "in the next " + strconv.Itoa(days) + " days"}, nil
+	case "today":
+		return labeled("today", now, today.AddDate(0, 0, 1)), nil
+	case "tonight":
+		start := today.Add(17 * time.Hour)
+		if now.After(start) {
+			start = now
+		}
+		return labeled("tonight", start, today.AddDate(0, 0, 1)), nil
+	case "tomorrow":
+		return labeled("tomorrow", today.AddDate(0, 0, 1), today.AddDate(0, 0, 2)), nil
+	case "this weekend", "next weekend":
+		// The weekend runs from Friday evening until Monday morning.
+		friday := today.AddDate(0, 0, (int(time.Friday)-int(today.Weekday())+7)%7)
+		if today.Weekday() == time.Saturday || today.Weekday() == time.Sunday {
+			friday = today.AddDate(0, 0, -((int(today.Weekday()) + 2) % 7))
+		}
+		if when == "next weekend" {
+			friday = friday.AddDate(0, 0, 7)
+		}
+		start := friday.Add(17 * time.Hour)
+		if now.After(start) {
+			start = now
+		}
+		return labeled(when, start, friday.AddDate(0, 0, 3)), nil
+	case "this week", "next week":
+		// Weeks start on Monday.
+		monday := today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+		if when == "this week" {
+			return labeled(when, now, monday.AddDate(0, 0, 7)), nil
+		}
+		return labeled(when, monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 14)), nil
+	case "this month":
+		first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+		return labeled(when, now, first.AddDate(0, 1, 0)), nil
+	case "next month":
+		first := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location()).AddDate(0, 1, 0)
+		return labeled(when, first, first.AddDate(0, 1, 0)), nil
+	}
+	if strings.HasPrefix(when, "in ") {
+		month, ok := months[strings.TrimPrefix(when, "in ")]
+		if !ok {
+			return window{}, errors.New("I don't know the month in \"" + when + "\".")
+		}
+		year := now.Year()
+		if month < now.Month() {
+			year++
+		}
+		first := time.Date(year, month, 1, 0, 0, 0, 0, now.Location())
+		start := first
+		if now.After(start) {
+			start = now
+		}
+		return labeled("in "+month.String(), start, first.AddDate(0, 1, 0)), nil
+	}
+	return explicitWindow(when, now)
+}
+
+// explicitWindow resolves a date or date range like "10/20" or "10/20-10/25". Dates without a year are taken to be the
+// next time that date comes around.
+func explicitWindow(when string, now time.Time) (window, error) {
+	m := datePattern.FindStringSubmatch(when)
+	if m == nil {
+		return window{}, errors.New("Couldn't understand the dates \"" + when + "\".")
+	}
+	today := midnight(now)
+	start, err := calendarDate(m[1], m[2], m[3], now)
+	if err != nil {
+		return window{}, err
+	}
+	if m[3] == "" && start.Before(today) {
+		start = start.AddDate(1, 0, 0)
+	}
+	end := start
+	if m[4] != "" {
+		end, err = calendarDate(m[4], m[5], m[6], start)
+		if err != nil {
+			return window{}, err
+		}
+		if m[6] == "" && end.Before(start) {
+			end = end.AddDate(1, 0, 0)
+		}
+	}
+	if end.Before(start) {
+		return window{}, errors.New("The end of \"" + when + "\" comes before its start.")
+	}
+	end = end.AddDate(0, 0, 1)
+	if !end.After(now) {
+		return window{}, errors.New("\"" + when + "\" is already over.")
+	}
+	if start.Before(now) {
+		start = now
+	}
+	return labeled("", start, end), nil
+}
+
+// calendarDate builds a date from month, day and optional year strings, using the year of ref when there's no year.
+func calendarDate(month, day, year string, ref time.Time) (time.Time, error) {
+	mo, _ := strconv.Atoi(month)
+	d, _ := strconv.Atoi(day)
+	y := ref.Year()
+	if year != "" {
+		y, _ = strconv.Atoi(year)
+		if y < 100 {
+			y += 2000
+		}
+	}
+	t := time.Date(y, time.Month(mo), d, 0, 0, 0, 0, ref.Location())
+	if mo < 1 || mo > 12 || t.Day() != d {
+		return time.Time{}, fmt.Errorf("%s/%s isn't a date.", month, day)
+	}
+	return t, nil
+}
+
+// labeled builds a window whose label names the phrase (if any) and the dates it covers.
+func labeled(phrase string, start, end time.Time) window {
+	last := end.Add(-time.Nanosecond)
+	dates := start.Format("Mon Jan 2")
+	if midnight(last) != midnight(start) {
+		dates += " - " + last.Format("Mon Jan 2")
+	}
+	label := dates
+	if phrase != "" {
+		label = phrase + " (" + dates + ")"
+	} else {
+		label = "for " + dates
+	}
+	return window{Start: start, End: end, Label: label}
+}
+
+// midnight returns the start of t's day, in t's time zone.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// eventfulDates renders the window in the "YYYYMMDDHH-YYYYMMDDHH" form the Eventful API takes.
+func (w window) eventfulDates() string {
+	return fmt.Sprintf("%d%02d%02d%02d-%d%02d%02d%02d",
+		w.Start.Year(), w.Start.Month(), w.Start.Day(), w.Start.Hour(),
+		w.End.Year(), w.End.Month(), w.End.Day(), w.End.Hour())
+}
+
+// describeStart says when an event starts for people in loc. Within the next week it's relative to now, like "today
+// 7:30pm", "tomorrow 7:30pm" or "in 3 days (Thu 7:30pm)", and otherwise it's the date, like "Sat 11/14 7:30pm".
+// All-day events are given by their date alone, e.g. "tomorrow (all day)".
+func describeStart(v Event, loc *time.Location, now time.Time) string {
+	start := v.StartIn(loc).In(loc)
+	now = now.In(loc)
+	date := start.Format("Mon 1/2")
+	if start.Year() != now.Year() {
+		date = start.Format("Mon 1/2/2006")
+	}
+	clock := start.Format("3:04pm")
+	// Days can be 23 or 25 hours long around daylight saving changes, so the count is rounded.
+	days := int(math.Floor(midnight(start).Sub(midnight(now)).Hours()/24 + 0.5))
+	if v.AllDay {
+		switch {
+		case days == 0:
+			return "today (all day)"
+		case days == 1:
+			return "tomorrow (all day)"
+		case days > 1 && days < 7:
+			return fmt.Sprintf("in %d days (%s, all day)", days, start.Format("Mon"))
+		}
+		return date + " (all day)"
+	}
+	switch {
+	case days == 0:
+		return "today " + clock
+	case days == 1:
+		return "tomorrow " + clock
+	case days > 1 && days < 7:
+		return fmt.Sprintf("in %d days (%s %s)", days, start.Format("Mon"), clock)
+	}
+	return date + " " + clock
+}
@@ -0,0 +1,53 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDescribeStart(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	at := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, newYork)
+	}
+	allDay := func(year int, month time.Month, day int) Event {
+		return Event{Start: time.Date(year, month, day, 0, 0, 0, 0, time.UTC), AllDay: true}
+	}
+	// 8pm on a Wednesday in New York is already Thursday in UTC.
+	wednesday := at(2026, 10, 21, 20, 0)
+	for _, test := range []struct {
+		name  string
+		event Event
+		now   time.Time
+		want  string
+	}{
+		{"later today", Event{Start: at(2026, 10, 21, 21, 30)}, wednesday, "today 9:30pm"},
+		{"today, given in UTC", Event{Start: time.Date(2026, 10, 22, 1, 0, 0, 0, time.UTC)}, wednesday, "today 9:00pm"},
+		{"tomorrow", Event{Start: at(2026, 10, 22, 7, 0)}, wednesday, "tomorrow 7:00am"},
+		{"in 3 days", Event{Start: at(2026, 10, 24, 19, 30)}, wednesday, "in 3 days (Sat 7:30pm)"},
+		{"in 6 days", Event{Start: at(2026, 10, 27, 19, 30)}, wednesday, "in 6 days (Tue 7:30pm)"},
+		{"a week away", Event{Start: at(2026, 10, 28, 19, 30)}, wednesday, "Wed 10/28 7:30pm"},
+		{"yesterday", Event{Start: at(2026, 10, 20, 19, 30)}, wednesday, "Tue 10/20 7:30pm"},
+		{"all day today", allDay(2026, 10, 21), wednesday, "today (all day)"},
+		{"all day tomorrow", allDay(2026, 10, 22), wednesday, "tomorrow (all day)"},
+		{"all day in 2 days", allDay(2026, 10, 23), wednesday, "in 2 days (Fri, all day)"},
+		{"all day later", allDay(2026, 11, 14), wednesday, "Sat 11/14 (all day)"},
+		{"next year, within the week", Event{Start: at(2027, 1, 2, 19, 0)}, at(2026, 12, 30, 20, 0),
+			"in 3 days (Sat 7:00pm)"},
+		{"next year", Event{Start: at(2027, 1, 10, 19, 0)}, at(2026, 12, 30, 20, 0), "Sun 1/10/2027 7:00pm"},
+		{"all day next year", allDay(2027, 1, 10), at(2026, 12, 30, 20, 0), "Sun 1/10/2027 (all day)"},
+		// March 8th 2026 is 23 hours long and November 1st is 25.
+		{"after a 23-hour day", Event{Start: at(2026, 3, 9, 0, 30)}, at(2026, 3, 8, 0, 30), "tomorrow 12:30am"},
+		{"two days after a 23-hour day", Event{Start: at(2026, 3, 10, 0, 30)}, at(2026, 3, 8, 0, 30),
+			"in 2 days (Tue 12:30am)"},
+		{"after a 25-hour day", Event{Start: at(2026, 11, 2, 23, 30)}, at(2026, 11, 1, 0, 30), "tomorrow 11:30pm"},
+		{"all day after a 25-hour day", allDay(2026, 11, 2), at(2026, 11, 1, 23, 30), "tomorrow (all day)"},
+	} {
+		if got := describeStart(test.event, newYork, test.now); got != test.want {
+			t.Errorf("%s: describeStart(%v, %v) = %q, want %q", test.name, test.event.Start, test.now, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/mentions"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Delivery modes for tracked searches. Immediate subscribers hear about new events as soon as they're found; daily
+// and weekly subscribers get them collected into a digest.
+const (
+	deliverImmediate = "immediate"
+	deliverDaily     = "daily"
+	deliverWeekly    = "weekly"
+)
+
+const (
+	digestsCollection = "groupmeEventDigestsV1"
+	// digestHour is the hour of the day, in the group's time zone, that digests are posted
+	digestHour = 9
+	// digestWeekday is the day weekly digests are posted
+	digestWeekday = time.Monday
+)
+
+// pendingDigest collects the new events for one search that are waiting to go out in a digest.
+type pendingDigest struct {
+	ID       bson.ObjectId `bson:"_id,omitempty"`
+	SearchID bson.ObjectId `bson:"search_id"`
+	GroupID  string        `bson:"group_id"`
+	Term     string        `bson:"term"`
+	Location string        `bson:"location"`
+	Delivery string        `bson:"delivery"`
+	Events   []Event       `bson:"events"`
+	// Due is when the digest is posted
+	Due time.Time `bson:"due"`
+}
+
+// parseDelivery reads a delivery mode, reporting whether it is one.
+func parseDelivery(word string) (string, bool) {
+	switch word {
+	case deliverImmediate, "instant", "now":
+		return deliverImmediate, true
+	case deliverDaily, "day":
+		return deliverDaily, true
+	case deliverWeekly, "week":
+		return deliverWeekly, true
+	}
+	return "", false
+}
+
+// delivery returns how a user wants to hear about new events; users saved before there was a choice hear right away.
+func (u user) delivery() string {
+	if u.Delivery == "" {
+		return deliverImmediate
+	}
+	return u.Delivery
+}
+
+// nextDigest returns when the next digest of the given mode is posted after now, in the zone of now.
+func nextDigest(delivery string, now time.Time) time.Time {
+	due := time.Date(now.Year(), now.Month(), now.Day(), digestHour, 0, 0, 0, now.Location())
+	if delivery == deliverWeekly {
+		due = due.AddDate(0, 0, (int(digestWeekday)-int(due.Weekday())+7)%7)
+		if !due.After(now) {
+			due = due.AddDate(0, 0, 7)
+		}
+		return due
+	}
+	if !due.After(now) {
+		due = due.AddDate(0, 0, 1)
+	}
+	return due
+}
+
+// setDelivery changes how the sender hears about new events for a tracked search term, e.g.
+// "!events delivery jazz weekly".
+func setDelivery(text string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	usage := "Use \"!events delivery <term> immediate\", \"daily\" or \"weekly\"."
+	words := strings.Fields(text)
+	if len(words) < 2 {
+		return &bot.OutgoingMessage{Text: usage}
+	}
+	delivery, ok := parseDelivery(words[len(words)-1])
+	if !ok {
+		return &bot.OutgoingMessage{Text: usage}
+	}
+	term := strings.Join(words[:len(words)-1], " ")
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	info, err := sess.DB(DB).C(searchesCollection).UpdateAll(
+		bson.M{"term": term, "group_id": message.GroupID, "users.user_id": message.UserID},
+		bson.M{"$set": bson.M{"users.$.delivery": delivery}})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	if info.Updated == 0 {
+		return &bot.OutgoingMessage{Text: "You aren't tracking \"" + term + "\". See yours with \"!events mine\"."}
+	}
+	return &bot.OutgoingMessage{Text: "You'll now get new events for \"" + term + "\" " + describeDelivery(delivery) + "."}
+}
+
+// describeDelivery says when a delivery mode's subscribers hear about new events.
+func describeDelivery(delivery string) string {
+	switch delivery {
+	case deliverDaily:
+		return fmt.Sprintf("in a daily digest at %d:00", digestHour)
+	case deliverWeekly:
+		return fmt.Sprintf("in a weekly digest on %ss at %d:00", digestWeekday, digestHour)
+	}
+	return "as soon as they're found"
+}
+
+// queueDigest adds new events to the digests waiting to go out for a search, one for each delivery mode its users
+// have asked for besides immediate.
+func (handler Handler) queueDigest(sess *mgo.Session, search eventSearch, events []Event) {
+	modes := make(map[string]bool)
+	for _, u := range search.Users {
+		if d := u.delivery(); d != deliverImmediate {
+			modes[d] = true
+		}
+	}
+	if len(modes) == 0 {
+		return
+	}
+	now := time.Now().In(handler.GroupTimezone(search.GroupID))
+	col := sess.DB(DB).C(digestsCollection)
+	for delivery := range modes {
+		_, err := col.Upsert(bson.M{"search_id": search.ID, "delivery": delivery}, bson.M{
+			"$setOnInsert": bson.M{
+				"group_id": search.GroupID,
+				"term":     search.Term,
+				"location": search.Location,
+				"due":      nextDigest(delivery, now),
+			},
+			"$push": bson.M{"events": bson.M{"$each": events}},
+		})
+		if err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// sendDigests posts groupID's digests that are due, one summary for each delivery mode, and clears them.
+func (handler Handler) sendDigests(sess *mgo.Session, groupID string, c chan *bot.OutgoingMessage) {
+	col := sess.DB(DB).C(digestsCollection)
+	var due []pendingDigest
+	err := col.Find(bson.M{"group_id": groupID, "due": bson.M{"$lte": time.Now()}}).Sort("delivery", "term").All(&due)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	loc := handler.GroupTimezone(groupID)
+	for len(due) > 0 {
+		n := 1
+		for n < len(due) && due[n].Delivery == due[0].Delivery {
+			n++
+		}
+		for _, m := range digestMessages(sess, due[:n], loc) {
+			c <- m
+		}
+		for _, digest := range due[:n] {
+			if err := col.RemoveId(digest.ID); err != nil {
+				fmt.Println(err)
+			}
+		}
+		due = due[n:]
+	}
+}
+
+// digestMessages renders a group's digests as one summary, a section for each search with its events in date order and
+// their times in loc, each mentioning only the users who get that search in this kind of digest. Sections are split
+// across messages when they don't fit in one.
+func digestMessages(sess *mgo.Session, digests []pendingDigest, loc *time.Location) []*bot.OutgoingMessage {
+	searches := make(map[bson.ObjectId]eventSearch, len(digests))
+	for _, digest := range digests {
+		var search eventSearch
+		if err := sess.DB(DB).C(searchesCollection).FindId(digest.SearchID).One(&search); err == nil {
+			searches[digest.SearchID] = search
+		}
+	}
+	return renderDigests(digests, searches, loc, time.Now())
+}
+
+// renderDigests does the work of digestMessages once the digests' searches have been looked up, leaving out events
+// that have started by now.
+func renderDigests(digests []pendingDigest, searches map[bson.ObjectId]eventSearch, loc *time.Location,
+	now time.Time) []*bot.OutgoingMessage {
+	var messages []*bot.OutgoingMessage
+	written := false
+	current := mentions.New("Your " + digests[0].Delivery + " events digest:")
+	for _, digest := range digests {
+		search, ok := searches[digest.SearchID]
+		if !ok {
+			// The search has been stopped since the events were found.
+			continue
+		}
+		var users []mentions.User
+		for _, u := range search.Users {
+			if u.delivery() == digest.Delivery {
+				users = append(users, mentions.User{ID: u.UserID, Name: u.Name})
+			}
+		}
+		if len(users) == 0 {
+			continue
+		}
+		// Events that have started since they were found are left out.
+		var events []Event
+		for _, v := range digest.Events {
+			if v.StartIn(loc).After(now) {
+				events = append(events, v)
+			}
+		}
+		if len(events) == 0 {
+			continue
+		}
+		sortEvents(events, "", loc)
+		section := "\n\n" + describeSearch(search) + ":"
+		for i, v := range events {
+			if i == maxAnnounce {
+				section += fmt.Sprintf("\n…and %d more. See them with \"!events %s\".", len(events)-i, search.Term)
+				break
+			}
+			section += "\n" + FormatEvent(v, loc)
+		}
+		tags := mentions.New("")
+		tags.MentionAll(users)
+		if current.Len()+len(section)+1+tags.Len() > maxMessage && written {
+			messages = append(messages, current.Message())
+			current = mentions.New("")
+			section = strings.TrimPrefix(section, "\n\n")
+		}
+		current.WriteString(section + "\n")
+		current.MentionAll(users)
+		written = true
+	}
+	if written {
+		messages = append(messages, current.Message())
+	}
+	return messages
+}
@@ -0,0 +1,151 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/mgo.v2/bson"
+)
+
+func TestNextDigest(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	for _, test := range []struct {
+		name     string
+		delivery string
+		now      time.Time
+		want     time.Time
+	}{
+		{"daily, before the hour", deliverDaily,
+			time.Date(2026, 10, 21, 8, 59, 0, 0, newYork), time.Date(2026, 10, 21, 9, 0, 0, 0, newYork)},
+		{"daily, on the hour", deliverDaily,
+			time.Date(2026, 10, 21, 9, 0, 0, 0, newYork), time.Date(2026, 10, 22, 9, 0, 0, 0, newYork)},
+		{"daily, end of the month", deliverDaily,
+			time.Date(2026, 10, 31, 9, 30, 0, 0, newYork), time.Date(2026, 11, 1, 9, 0, 0, 0, newYork)},
+		{"weekly, midweek", deliverWeekly,
+			time.Date(2026, 10, 21, 12, 0, 0, 0, newYork), time.Date(2026, 10, 26, 9, 0, 0, 0, newYork)},
+		{"weekly, Monday before the hour", deliverWeekly,
+			time.Date(2026, 10, 26, 8, 0, 0, 0, newYork), time.Date(2026, 10, 26, 9, 0, 0, 0, newYork)},
+		{"weekly, Monday on the hour", deliverWeekly,
+			time.Date(2026, 10, 26, 9, 0, 0, 0, newYork), time.Date(2026, 11, 2, 9, 0, 0, 0, newYork)},
+		{"weekly, Sunday", deliverWeekly,
+			time.Date(2026, 11, 1, 23, 0, 0, 0, newYork), time.Date(2026, 11, 2, 9, 0, 0, 0, newYork)},
+		{"weekly, across the end of the year", deliverWeekly,
+			time.Date(2026, 12, 29, 9, 0, 0, 0, newYork), time.Date(2027, 1, 4, 9, 0, 0, 0, newYork)},
+		// Clocks go back on November 1st and forward on March 14th; digests stay at 9am local time.
+		{"daily, across the end of daylight saving", deliverDaily,
+			time.Date(2026, 10, 31, 10, 0, 0, 0, newYork), time.Date(2026, 11, 1, 9, 0, 0, 0, newYork)},
+		{"weekly, across the start of daylight saving", deliverWeekly,
+			time.Date(2027, 3, 8, 10, 0, 0, 0, newYork), time.Date(2027, 3, 15, 9, 0, 0, 0, newYork)},
+	} {
+		got := nextDigest(test.delivery, test.now)
+		if !got.Equal(test.want) || got.Location() != newYork {
+			t.Errorf("%s: nextDigest(%s, %v) = %v, want %v", test.name, test.delivery, test.now, got, test.want)
+		}
+	}
+}
+
+func TestRenderDigests(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	jazz, blues, stopped := bson.NewObjectId(), bson.NewObjectId(), bson.NewObjectId()
+	searches := map[bson.ObjectId]eventSearch{
+		jazz: {Term: "jazz", Location: "Tampa", Users: []user{
+			{UserID: "1", Name: "Ann", Delivery: deliverDaily},
+			{UserID: "2", Name: "Bo", Delivery: deliverWeekly},
+		}},
+		blues: {Term: "blues", Users: []user{{UserID: "3", Name: "Cy", Delivery: deliverDaily}}},
+	}
+	digests := []pendingDigest{
+		{SearchID: jazz, Delivery: deliverDaily, Events: []Event{
+			{ID: "late", Title: "Late set", Start: now.Add(48 * time.Hour)},
+			{ID: "early", Title: "Early set", Start: now.Add(24 * time.Hour)},
+			{ID: "gone", Title: "Started already", Start: now.Add(-time.Hour)},
+		}},
+		{SearchID: stopped, Delivery: deliverDaily, Events: []Event{
+			{ID: "x", Title: "Stopped", Start: now.Add(time.Hour)},
+		}},
+		{SearchID: blues, Delivery: deliverDaily, Events: []Event{
+			{ID: "b", Title: "Blues brunch", Start: now.Add(time.Hour)},
+		}},
+	}
+	messages := renderDigests(digests, searches, time.UTC, now)
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	text := messages[0].Text
+	if !strings.HasPrefix(text, "Your daily events digest:\n\n\"jazz\" near Tampa:\n") {
+		t.Errorf("text = %q", text)
+	}
+	if early, late := strings.Index(text, "Early set"), strings.Index(text, "Late set"); early < 0 || late < early {
+		t.Errorf("events out of date order: %q", text)
+	}
+	for _, missing := range []string{"Started already", "Stopped", "@Bo"} {
+		if strings.Contains(text, missing) {
+			t.Errorf("text has %q: %q", missing, text)
+		}
+	}
+	if !strings.Contains(text, "\n@Ann") || !strings.Contains(text, "\"blues\":\n") || !strings.HasSuffix(text, "\n@Cy") {
+		t.Errorf("text = %q", text)
+	}
+	if ids := messages[0].Attachments[0].UserIDs; len(ids) != 2 || ids[0] != 1 || ids[1] != 3 {
+		t.Errorf("mentioned %v, want [1 3]", ids)
+	}
+
+	// Nothing left to post means no messages at all.
+	if messages := renderDigests(digests[1:2], searches, time.UTC, now); len(messages) != 0 {
+		t.Errorf("got %d messages for a stopped search, want none", len(messages))
+	}
+}
+
+func TestRenderDigestsSplits(t *testing.T) {
+	now := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	searches := make(map[bson.ObjectId]eventSearch)
+	var digests []pendingDigest
+	for i := 0; i < 4; i++ {
+		id := bson.NewObjectId()
+		searches[id] = eventSearch{Term: fmt.Sprintf("search %d", i), Users: []user{
+			{UserID: fmt.Sprint(i + 1), Name: "User", Delivery: deliverWeekly},
+		}}
+		digest := pendingDigest{SearchID: id, Delivery: deliverWeekly}
+		for j := 0; j < maxAnnounce+2; j++ {
+			digest.Events = append(digest.Events, Event{
+				ID:    fmt.Sprintf("%d-%d", i, j),
+				Title: fmt.Sprintf("A concert with a fairly long name, number %d", j),
+				Start: now.Add(time.Duration(j+1) * time.Hour),
+			})
+		}
+		digests = append(digests, digest)
+	}
+	messages := renderDigests(digests, searches, time.UTC, now)
+	if len(messages) < 2 {
+		t.Fatalf("got %d messages, want the digest split", len(messages))
+	}
+	if !strings.HasPrefix(messages[0].Text, "Your weekly events digest:\n\n\"search 0\":") {
+		t.Errorf("first message = %q", messages[0].Text)
+	}
+	sections := 0
+	for i, m := range messages {
+		if i > 0 && !strings.HasPrefix(m.Text, "\"search ") {
+			t.Errorf("message %d doesn't start with a section: %q", i, m.Text)
+		}
+		// Each section's mentions go in the same message as its events.
+		n := strings.Count(m.Text, "\"search ")
+		if len(m.Attachments) != 1 || len(m.Attachments[0].UserIDs) != n {
+			t.Errorf("message %d has %d sections and attachments %+v", i, n, m.Attachments)
+		}
+		if n > 1 && len(m.Text) > maxMessage {
+			t.Errorf("message %d is %d bytes, over %d", i, len(m.Text), maxMessage)
+		}
+		if !strings.Contains(m.Text, "…and 2 more. See them with \"!events search ") {
+			t.Errorf("message %d doesn't cut its events short: %q", i, m.Text)
+		}
+		sections += n
+	}
+	if sections != 4 {
+		t.Errorf("%d sections posted, want 4", sections)
+	}
+}
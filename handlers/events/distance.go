@@ -0,0 +1,171 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// point is a place on the globe, in degrees.
+type point struct {
+	Latitude  float64 `bson:"latitude"`
+	Longitude float64 `bson:"longitude"`
+}
+
+const (
+	// earthRadius is the Earth's mean radius in miles.
+	earthRadius = 3958.8
+	// searchLimit is the number of results asked of the provider for a search, so that there are enough left to show
+	// once the ones outside the radius are dropped and the rest sorted.
+	searchLimit = 50
+	// sortDistance is the "sort:" modifier that orders results nearest first.
+	sortDistance = "distance"
+)
+
+// parsePoint reads coordinates written as "<latitude>,<longitude>", like "27.77,-82.64".
+func parsePoint(text string) (point, error) {
+	parts := strings.Split(text, ",")
+	if len(parts) != 2 {
+		return point{}, errors.New("coordinates should look like \"27.77,-82.64\"")
+	}
+	lat, err := strconv.ParseFloat(strings.TrimSpace(parts[0]), 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return point{}, errors.New("latitude should be a number from -90 to 90")
+	}
+	lng, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64)
+	if err != nil || lng < -180 || lng > 180 {
+		return point{}, errors.New("longitude should be a number from -180 to 180")
+	}
+	return point{Latitude: lat, Longitude: lng}, nil
+}
+
+// String writes the point the way parsePoint reads it.
+func (p point) String() string {
+	return strconv.FormatFloat(p.Latitude, 'f', -1, 64) + "," + strconv.FormatFloat(p.Longitude, 'f', -1, 64)
+}
+
+// haversine returns the great-circle distance between two points in miles.
+func haversine(a, b point) float64 {
+	rad := math.Pi / 180
+	dLat := (b.Latitude - a.Latitude) * rad
+	dLng := (b.Longitude - a.Longitude) * rad
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(a.Latitude*rad)*math.Cos(b.Latitude*rad)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// formatDistance renders a distance in miles for people, e.g. "12 mi" or "0.4 mi".
+func formatDistance(miles float64) string {
+	if miles < 10 {
+		return fmt.Sprintf("%.1f mi", miles)
+	}
+	return fmt.Sprintf("%.0f mi", miles)
+}
+
+// measure sets the distance from home of each event whose venue coordinates the provider gave.
+func measure(events []Event, home point) {
+	for i, v := range events {
+		if v.Latitude != 0 || v.Longitude != 0 {
+			events[i].Distance = haversine(home, point{Latitude: v.Latitude, Longitude: v.Longitude})
+		}
+	}
+}
+
+// withinRadius drops the measured events farther than radius miles, for searches run around the point they were
+// measured from. Providers don't agree on what their own radius covers, so results are checked here too; events without
+// coordinates are kept, since there's no telling.
+func withinRadius(events []Event, radius int) []Event {
+	kept := events[:0]
+	for _, v := range events {
+		if v.Distance <= float64(radius) {
+			kept = append(kept, v)
+		}
+	}
+	return kept
+}
+
+// sortEvents puts events in order of when they start in loc, or nearest first when sortBy is "distance", with events
+// of unknown distance last.
+func sortEvents(events []Event, sortBy string, loc *time.Location) {
+	sort.SliceStable(events, func(i, j int) bool { return events[i].StartIn(loc).Before(events[j].StartIn(loc)) })
+	if sortBy != sortDistance {
+		return
+	}
+	sort.SliceStable(events, func(i, j int) bool {
+		a, b := events[i].Distance, events[j].Distance
+		if a == 0 || b == 0 {
+			return a != 0 && b == 0
+		}
+		return a < b
+	})
+}
+
+// isCentre reports whether a search's location is the home itself, written as coordinates, so that the radius around
+// one is the radius around the other.
+func isCentre(location string, home point) bool {
+	centre, err := parsePoint(location)
+	return err == nil && haversine(centre, home) < 0.1
+}
+
+// groupHome returns the point a group's results are measured from: its own home if it has set one, or the handler's
+// default. It reports false when neither is set.
+func (handler Handler) groupHome(groupID string) (point, bool) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer sess.Close()
+		settings, err := findGroup(sess, groupID)
+		if err != nil {
+			fmt.Println(err)
+		}
+		if settings.Home != nil {
+			return *settings.Home, true
+		}
+	}
+	if handler.Home == "" {
+		return point{}, false
+	}
+	home, err := parsePoint(handler.Home)
+	if err != nil {
+		fmt.Println("events home:", err)
+		return point{}, false
+	}
+	return home, true
+}
+
+// setHome saves the point a group's results are measured from, e.g. "!events home 27.77,-82.64".
+func (handler Handler) setHome(groupID string, args []string) *bot.OutgoingMessage {
+	if len(args) == 0 {
+		if home, ok := handler.groupHome(groupID); ok {
+			return &bot.OutgoingMessage{Text: "Distances to events here are measured from " + home.String() +
+				". Change it with \"!events home <latitude>,<longitude>\"."}
+		}
+		return &bot.OutgoingMessage{Text: "No home is set for measuring distances to events. Set one with " +
+			"\"!events home <latitude>,<longitude>\", e.g. \"!events home 27.77,-82.64\"."}
+	}
+	home, err := parsePoint(strings.Join(args, ""))
+	if err != nil {
+		return &bot.OutgoingMessage{Text: "Couldn't read those coordinates: " + err.Error() + "."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	_, err = sess.DB(DB).C(groupsCollection).Upsert(bson.M{"group_id": groupID}, bson.M{
+		"$set": bson.M{"home": home},
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "Distances to events here will now be measured from " + home.String() + "."}
+}
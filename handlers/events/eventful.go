@@ -0,0 +1,191 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/sha1sum/eventful"
+)
+
+// DefaultEventfulURL is the root of the Eventful JSON API.
+const DefaultEventfulURL = "http://api.eventful.com/json"
+
+// EventfulProvider searches the Eventful API, or another API that answers the same way.
+type EventfulProvider struct {
+	Key string
+	// URL is the root of the API; blank means DefaultEventfulURL
+	URL string
+	// Client makes the requests; nil means a client with a 15 second timeout
+	Client *http.Client
+}
+
+// eventfulTime is the layout of the times in Eventful's responses. They're given without a zone, in the venue's.
+const eventfulTime = "2006-01-02 15:04:05"
+
+// eventfulDetails is the part of an Eventful events/get response that's used.
+type eventfulDetails struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	URL         string `json:"url"`
+	Description string `json:"description"`
+	StartTime   string `json:"start_time"`
+	StopTime    string `json:"stop_time"`
+	AllDay      string `json:"all_day"`
+	Created     string `json:"created"`
+	VenueName   string `json:"venue_name"`
+	City        string `json:"city"`
+	Latitude    string `json:"latitude"`
+	Longitude   string `json:"longitude"`
+	// OlsonPath is the IANA name of the venue's zone
+	OlsonPath string `json:"olson_path"`
+}
+
+// eventfulSearch is an Eventful events/search response, read with the venue zones the eventful package leaves out.
+type eventfulSearch struct {
+	TotalItems int `json:"total_items,string"`
+	PageNumber int `json:"page_number,string"`
+	PageCount  int `json:"page_count,string"`
+	Events     struct {
+		Events []eventfulResult `json:"event"`
+	} `json:"events"`
+}
+
+// eventfulResult is an event in a search response.
+type eventfulResult struct {
+	eventful.Event
+	// OlsonPath is the IANA name of the venue's zone
+	OlsonPath string `json:"olson_path"`
+}
+
+// Search returns one page of the events matching a request. Times are read in the venue's zone, or the request's for
+// results that don't give one.
+func (p EventfulProvider) Search(req SearchRequest, page, perPage int) (Results, error) {
+	sort := req.Sort
+	if sort == "" {
+		sort = "date"
+	}
+	params := url.Values{
+		"app_key":     {p.Key},
+		"keywords":    {req.Term},
+		"location":    {req.Location},
+		"within":      {strconv.Itoa(req.Radius)},
+		"sort_order":  {sort},
+		"page_size":   {strconv.Itoa(perPage)},
+		"page_number": {strconv.Itoa(page)},
+	}
+	if !req.Start.IsZero() {
+		params.Set("date", window{Start: req.Start, End: req.End}.eventfulDates())
+	}
+	var res eventfulSearch
+	if err := p.get("/events/search", params, &res); err != nil {
+		return Results{}, err
+	}
+	results := Results{Total: res.TotalItems, Page: res.PageNumber, PageCount: res.PageCount}
+	for _, v := range res.Events.Events {
+		results.Events = append(results.Events, fromEventful(v.Event, loadZone(v.OlsonPath, req.zone())))
+	}
+	return results, nil
+}
+
+// SearchAll returns the events matching a request from up to maxPages pages of 100.
+func (p EventfulProvider) SearchAll(req SearchRequest, maxPages int) ([]Event, error) {
+	return searchAllPages(p, req, 100, maxPages)
+}
+
+// Get returns the event with the given Eventful ID.
+func (p EventfulProvider) Get(id string) (Event, error) {
+	var details eventfulDetails
+	if err := p.get("/events/get", url.Values{"app_key": {p.Key}, "id": {id}}, &details); err != nil {
+		return Event{}, err
+	}
+	if details.ID == "" {
+		return Event{}, errNoEvent
+	}
+	loc := loadZone(details.OlsonPath, time.UTC)
+	start, end, allDay := eventfulTimes(details.StartTime, details.StopTime, details.AllDay, loc)
+	created, _ := time.ParseInLocation(eventfulTime, details.Created, loc)
+	lat, _ := strconv.ParseFloat(details.Latitude, 64)
+	lon, _ := strconv.ParseFloat(details.Longitude, 64)
+	return Event{
+		ID:          details.ID,
+		Title:       details.Title,
+		URL:         details.URL,
+		Description: details.Description,
+		VenueName:   details.VenueName,
+		City:        details.City,
+		Start:       start,
+		End:         end,
+		AllDay:      allDay,
+		Created:     created,
+		Latitude:    lat,
+		Longitude:   lon,
+	}, nil
+}
+
+// get requests a path under the API's root and decodes the JSON response into v.
+func (p EventfulProvider) get(path string, params url.Values, v interface{}) error {
+	root := p.URL
+	if root == "" {
+		root = DefaultEventfulURL
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Get(root + path + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Eventful responded with HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// eventfulTimes reads an Eventful event's start and stop times in loc. Eventful's all_day is "1" for all-day events
+// and "2" for events without a set time, which are both treated as all-day and kept at midnight UTC.
+func eventfulTimes(startTime, stopTime, allDay string, loc *time.Location) (time.Time, time.Time, bool) {
+	whole := allDay != "" && allDay != "0"
+	if whole {
+		loc = time.UTC
+	}
+	start, _ := time.ParseInLocation(eventfulTime, startTime, loc)
+	end, _ := time.ParseInLocation(eventfulTime, stopTime, loc)
+	return start, end, whole
+}
+
+// fromEventful converts an event from an Eventful search, reading its times in loc.
+func fromEventful(v eventful.Event, loc *time.Location) Event {
+	start, end, allDay := eventfulTimes(v.StartTime, v.StopTime, v.AllDay, loc)
+	created, _ := time.ParseInLocation(eventfulTime, v.Created, loc)
+	lat, _ := strconv.ParseFloat(v.Latitude, 64)
+	lon, _ := strconv.ParseFloat(v.Longitude, 64)
+	event := Event{
+		ID:          v.ID,
+		Title:       v.Title,
+		URL:         v.URL,
+		Description: v.Description,
+		VenueName:   v.VenueName,
+		City:        v.CityName,
+		Start:       start,
+		End:         end,
+		AllDay:      allDay,
+		Created:     created,
+		Latitude:    lat,
+		Longitude:   lon,
+	}
+	if v.Image != nil {
+		switch {
+		case v.Image.Medium != nil:
+			event.ImageURL = v.Image.Medium.URL
+		case v.Image.URL != "":
+			event.ImageURL = v.Image.URL
+		}
+	}
+	return event
+}
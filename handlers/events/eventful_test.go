@@ -0,0 +1,110 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// eventfulStandIn answers Eventful searches with five matching events, paged, and gets with one known event.
+func eventfulStandIn(t *testing.T) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("app_key") != "key" {
+			t.Errorf("app_key = %q, want key", q.Get("app_key"))
+		}
+		switch r.URL.Path {
+		case "/events/search":
+			var page, size int
+			fmt.Sscan(q.Get("page_number"), &page)
+			fmt.Sscan(q.Get("page_size"), &size)
+			events := ""
+			for i := (page - 1) * size; i < page*size && i < 5; i++ {
+				if events != "" {
+					events += ","
+				}
+				zone := ""
+				if i%2 == 1 {
+					zone = "America/Chicago"
+				}
+				events += fmt.Sprintf(`{"id":"E%d","title":"Jazz %d","start_time":"2026-10-2%d 19:30:00","all_day":"0",`+
+					`"olson_path":"%s"}`, i, i, i, zone)
+			}
+			fmt.Fprintf(w, `{"total_items":"5","page_number":"%d","page_size":"%d","page_count":"%d","events":{"event":[%s]}}`,
+				page, size, (5+size-1)/size, events)
+		case "/events/get":
+			switch q.Get("id") {
+			case "E1":
+				fmt.Fprint(w, `{"id":"E1","title":"Jazz 1","start_time":"2026-10-21 19:30:00","all_day":"0",`+
+					`"olson_path":"America/Chicago"}`)
+			case "E2":
+				fmt.Fprint(w, `{"id":"E2","title":"Jazz 2","start_time":"2026-10-22 00:00:00","all_day":"1"}`)
+			default:
+				fmt.Fprint(w, `{}`)
+			}
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestEventfulSearch(t *testing.T) {
+	server := eventfulStandIn(t)
+	defer server.Close()
+	p := EventfulProvider{Key: "key", URL: server.URL}
+	req := SearchRequest{Term: "jazz", Location: "33701", Radius: 25, Timezone: "America/Denver"}
+
+	res, err := p.Search(req, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 5 || res.Page != 2 || res.PageCount != 3 || len(res.Events) != 2 || res.Events[0].ID != "E2" {
+		t.Errorf("page 2 = %+v", res)
+	}
+	// Times are read in the venue's zone, or the request's when the result doesn't name one.
+	denver, _ := time.LoadLocation("America/Denver")
+	if want := time.Date(2026, 10, 22, 19, 30, 0, 0, denver); !res.Events[0].Start.Equal(want) {
+		t.Errorf("start without a venue zone = %v, want %v", res.Events[0].Start, want)
+	}
+	chicago, _ := time.LoadLocation("America/Chicago")
+	if want := time.Date(2026, 10, 23, 19, 30, 0, 0, chicago); !res.Events[1].Start.Equal(want) {
+		t.Errorf("start with a venue zone = %v, want %v", res.Events[1].Start, want)
+	}
+
+	all, err := p.SearchAll(req, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(all) != 5 {
+		t.Errorf("SearchAll found %d events, want 5", len(all))
+	}
+}
+
+func TestEventfulGet(t *testing.T) {
+	server := eventfulStandIn(t)
+	defer server.Close()
+	p := EventfulProvider{Key: "key", URL: server.URL}
+
+	v, err := p.Get("E1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chicago, _ := time.LoadLocation("America/Chicago")
+	if want := time.Date(2026, 10, 21, 19, 30, 0, 0, chicago); !v.Start.Equal(want) {
+		t.Errorf("start = %v, want %v in the venue's zone", v.Start, want)
+	}
+
+	v, err = p.Get("E2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 22, 0, 0, 0, 0, time.UTC); !v.AllDay || !v.Start.Equal(want) {
+		t.Errorf("all-day event = %v (all day %v), want %v", v.Start, v.AllDay, want)
+	}
+
+	if _, err := p.Get("missing"); err != errNoEvent {
+		t.Errorf("Get(missing) error = %v, want errNoEvent", err)
+	}
+}
@@ -1,5 +1,30 @@
 /*
-Package events handles searching for Eventful events and announcing matching results in the area.
+Package events handles searching for events and announcing matching results in the area. Events come from an
+EventProvider: the Eventful API, a Ticketmaster Discovery-style API or an iCalendar feed, chosen with NewProvider.
+
+Searches are run around the group's location, which is set with "!events location <zip or city>", unless the search
+names its own place and distance, e.g. "!events jazz near tampa, fl within 25mi".
+
+Groups can also set a home with "!events home <latitude>,<longitude>". Results around the group's location then show
+how far away each venue is, and "!events jazz sort:distance" lists the nearest first. When the group's location is the
+same coordinates, anything farther from home than the search's distance is left out too.
+
+Nobody hears about new events for a search until they ask with "!events track <term>", and "!events stop <term>"
+undoes it. "!events mine" lists the searches you're tracking, and "!events tracked" lists everyone's in the group.
+New events are announced as soon as they're found, unless you'd rather get them in a daily or weekly digest:
+"!events track jazz weekly", or "!events delivery jazz daily" for a search you're already tracking.
+
+"!events add <number>" puts an event from the last list on the group's GroupMe calendar.
+
+Groups can also have iCalendar feeds searched alongside the provider, with "!events calendar add <url>".
+
+Results are shown ten at a time; "!events more" shows the next ten from your last search. When there are only a few,
+each gets its own message, with a location attachment for its venue and an image attachment for its picture if the
+handler has Attachments turned on. Longer lists are condensed into numbered messages, which never get attachments.
+
+Searches cover the next 30 days unless they say when, e.g. "!events jazz this weekend", "tonight", "next week",
+"in december" or "10/20-10/25". Those dates are read, and event times are shown, in the group's time zone, which is
+set with "!events timezone <zone>".
 */
 package events
 
@@ -7,12 +32,13 @@ import (
 	"time"
 
 	"fmt"
-	"strconv"
 
-	"os"
+	"strconv"
 	"strings"
 
-	"github.com/sha1sum/eventful"
+	"github.com/sha1sum/distinguished_taste_society_bots/groupme"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/mentions"
 	"github.com/sha1sum/golang_groupme_bot/bot"
 	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
@@ -20,212 +46,298 @@ import (
 
 // Handler will satisfy the bot.Handler interface.
 type Handler struct {
-	Key string
-	ZIP string
+	// Provider is where events are searched for
+	Provider EventProvider
+	// Location is the place searched around for groups that haven't set their own with "!events location". It can be
+	// a ZIP code or a place name like "St. Petersburg, FL".
+	Location string
 	// Radius is in miles
 	Radius int
 	// Days is the number of days in the future to search
 	Days int
 	// SortOrder is the field on which to sort events
 	SortOrder string
+	// Timezone is the IANA zone date phrases are read and event times shown in for groups that haven't set their own
+	// with "!events timezone"; it defaults to America/New_York
+	Timezone string
+	// Calendar creates GroupMe calendar events for "!events add"
+	Calendar groupme.CalendarService
+	// Attachments turns on a location attachment for each result's venue, and an image attachment for its picture, in
+	// lists short enough not to be condensed
+	Attachments bool
+	// Images re-hosts event pictures on GroupMe so they can be attached. Pictures are skipped when the service has no
+	// access token.
+	Images groupme.ImageService
+	// Condense is the number of results above which they're posted together in one numbered message, without
+	// attachments; it defaults to 3
+	Condense int
+	// Home is the point, as "<latitude>,<longitude>", that distances to events are measured from for groups that
+	// haven't set their own with "!events home"
+	Home string
+	// Narrow is how searches that find too many events are narrowed down; the zero value leaves them alone
+	Narrow Narrowing
 }
 
 type eventSearch struct {
-	Term          string    `bson:"term"`
+	ID      bson.ObjectId `bson:"_id,omitempty"`
+	Term    string        `bson:"term"`
+	GroupID string        `bson:"group_id"`
+	// Location and Radius are where the search is run; searches saved before they were stored use the handler's
+	Location      string    `bson:"location"`
+	Radius        int       `bson:"radius"`
 	Users         []user    `bson:"users"`
 	LatestCreated time.Time `bson:"latest_created"`
+	// Announced holds the IDs of the events already announced for the search, newest last
+	Announced []string `bson:"announced"`
+	// Checked is when the search was last run; a search that has never run announces nothing the first time
+	Checked time.Time `bson:"checked"`
 }
 
+const (
+	// maxAnnounced is the number of event IDs remembered for each search.
+	maxAnnounced = 1000
+	// maxAnnounce is the number of new events announced at once; any more are counted instead.
+	maxAnnounce = 15
+	// maxMessage is the most text put in one announcement message.
+	maxMessage = 1000
+	// createdSlack is how long before the newest creation time seen an unannounced event can have been created and
+	// still count as new, since providers don't always list events in the order they were created.
+	createdSlack = 7 * 24 * time.Hour
+)
+
 type user struct {
 	UserID string `bson:"user_id"`
+	// Name is the user's nickname when they started tracking the search, for mentioning them
+	Name string `bson:"name,omitempty"`
+	// Delivery is "immediate", "daily" or "weekly"; blank means immediate
+	Delivery string `bson:"delivery,omitempty"`
 }
 
 // DB is the name of the MongoDB database
-var DB string
+var DB = handlers.DB
 
-// Handle takes a search term and queries the Eventful API for matching results in the given ZIP code
+// Handle takes a search term and queries the handler's provider for matching results around the group's location, or the
+// place given in the search with "near <place>".
 func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
 	if message.SenderType == "bot" {
 		return
 	}
+	if words := strings.Fields(term); len(words) > 0 {
+		switch words[0] {
+		case "location":
+			c <- []*bot.OutgoingMessage{handler.setLocation(message.GroupID, strings.Join(words[1:], " "))}
+			return
+		case "timezone":
+			c <- []*bot.OutgoingMessage{handler.setTimezone(message.GroupID, message.Text)}
+			return
+		case "more":
+			c <- handler.more(message)
+			return
+		case "home":
+			c <- []*bot.OutgoingMessage{handler.setHome(message.GroupID, words[1:])}
+			return
+		case "track":
+			c <- []*bot.OutgoingMessage{handler.track(strings.Join(words[1:], " "), message)}
+			return
+		case "stop":
+			c <- []*bot.OutgoingMessage{stopTracking(strings.Join(words[1:], " "), message)}
+			return
+		case "mine":
+			c <- []*bot.OutgoingMessage{listMine(message)}
+			return
+		case "tracked":
+			c <- []*bot.OutgoingMessage{listTracked(message)}
+			return
+		case "add":
+			c <- []*bot.OutgoingMessage{handler.addToCalendar(argsAfter(message.Text, "add"), message)}
+			return
+		case "delivery":
+			c <- []*bot.OutgoingMessage{setDelivery(strings.Join(words[1:], " "), message)}
+			return
+		case "calendar", "calendars":
+			c <- []*bot.OutgoingMessage{calendarCommand(words[1:], message)}
+			return
+		}
+	}
+	q := parseQuery(term)
+	term = q.Term
 	if len(term) < 4 {
 		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "You must provide a search term at least 4 characters in length."}}
 		return
 	}
-	key := handler.Key
-	if len(key) < 1 {
-		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "Events API key is not yet set."}}
-		return
-	}
-	zip := handler.ZIP
-	if len(zip) != 5 {
-		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "ZIP code for event search is not yet set."}}
+	location, radius := handler.searchArea(q, message.GroupID)
+	if location == "" {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "No location is set for event searches. Set one with \"!events location <place>\"."}}
 		return
 	}
-	radius := handler.Radius
-	if radius == 0 {
-		radius = 100
-	}
 	days := handler.Days
 	if days == 0 {
 		days = 30
 	}
-	sort := handler.SortOrder
-	if len(sort) < 1 {
-		sort = "date"
+	loc := handler.GroupTimezone(message.GroupID)
+	span, err := resolveWindow(q.When, time.Now().In(loc), days)
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: err.Error()}}
+		return
 	}
-	client := eventful.New(key)
-	start := time.Now()
-	end := start.AddDate(0, 0, days)
-	dateString := fmt.Sprintf("%d%02d%02d00-%d%02d%02d00",
-		start.Year(), start.Month(), start.Day(), end.Year(), end.Month(), end.Day())
-	res, err := client.SearchEvents(term, dateString, zip, radius, sort, 10, 1)
+	req := SearchRequest{
+		Term:     term,
+		Location: location,
+		Radius:   radius,
+		Start:    span.Start,
+		End:      span.End,
+		Sort:     handler.SortOrder,
+		Timezone: loc.String(),
+	}
+	links := groupCalendars(message.GroupID)
+	if handler.Provider == nil && len(links) == 0 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "No events provider is set up yet. Add a calendar with \"!events calendar add <url>\"."}}
+		return
+	}
+	// Distances are measured from the group's home, so they only mean something around the group's own location.
+	home, measured := handler.groupHome(message.GroupID)
+	measured = measured && q.Location == ""
+	if q.Sort == sortDistance && !measured {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "Sorting by distance needs the group's home set with " +
+			"\"!events home <latitude>,<longitude>\", and only works for searches around the group's location."}}
+		return
+	}
+	cur := searchCursor{GroupID: message.GroupID, UserID: message.UserID, Request: req, Label: span.Label, Sort: q.Sort}
+	if measured {
+		cur.Home = &home
+		cur.Filter = isCentre(location, home)
+	}
+	if err := handler.search(&cur, links); err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	note, err := handler.narrow(&cur, q, links)
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	messages, err := handler.page(&cur, note)
 	if err != nil {
 		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
 		return
 	}
-	if len(res.Events) < 1 {
+	if messages == nil {
 		c <- []*bot.OutgoingMessage{
 			&bot.OutgoingMessage{
-				Text: "No events found for \"" + term + "\" in the next " + strconv.Itoa(days) + "days.",
+				Text: "No events found for \"" + term + "\" near " + location + " " + span.Label + ". " +
+					"Hear about new ones with \"!events track " + term + "\".",
 			},
-			handler.trackEvent(term, message),
 		}
 		return
 	}
-	if res.TotalItems > 10 {
-		res, err = client.SearchEvents(term, dateString, zip, 25, sort, 10, 1)
-		if err != nil {
-			c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
-			return
-		}
+	if sess, err := handlers.Dial(); err != nil {
+		fmt.Println(err)
+	} else {
+		saveCursor(sess, cur)
+		sess.Close()
 	}
-	messages := outputEvents(res.Events)
-	messages = append(messages, handler.trackEvent(term, message))
 	c <- messages
 }
 
-func outputEvents(events []eventful.Event) []*bot.OutgoingMessage {
+// outputEvents posts search results, numbered from first so that other commands can refer to them, with times shown
+// in loc. A few results get a message each, with attachments if the handler has them turned on; longer lists are
+// condensed into as few messages as fit, without any.
+func (handler Handler) outputEvents(events []Event, first int, loc *time.Location) []*bot.OutgoingMessage {
+	condense := handler.Condense
+	if condense == 0 {
+		condense = 3
+	}
+	if len(events) > condense {
+		// GroupMe cuts posts off at 1000 characters, so a long enough list still takes more than one.
+		var em []*bot.OutgoingMessage
+		text := ""
+		for i, v := range events {
+			line := strconv.Itoa(first+i) + ". " + FormatEvent(v, loc)
+			if text != "" && len(text)+1+len(line) > maxMessage {
+				em = append(em, &bot.OutgoingMessage{Text: text})
+				text = ""
+			}
+			if text != "" {
+				text += "\n"
+			}
+			text += line
+		}
+		return append(em, &bot.OutgoingMessage{Text: text})
+	}
 	em := make([]*bot.OutgoingMessage, 0)
-	intf := "2006-01-02 15:04:05"
-	outtf := "1/2/2006 3:04pm"
-	for _, v := range events {
-		startTime, _ := time.Parse(intf, v.StartTime)
-		text := fmt.Sprintf("%s: %s [at %s in %s] %s",
-			startTime.Format(outtf),
-			v.Title,
-			v.VenueName,
-			v.CityName,
-			v.URL,
-		)
-		em = append(em, &bot.OutgoingMessage{Text: text})
+	for i, v := range events {
+		m := &bot.OutgoingMessage{Text: strconv.Itoa(first+i) + ". " + FormatEvent(v, loc)}
+		if handler.Attachments {
+			m.Attachments = handler.eventAttachments(v)
+		}
+		em = append(em, m)
 	}
 	return em
 }
 
-func (handler Handler) trackEvent(term string, message bot.IncomingMessage) *bot.OutgoingMessage {
-	uri := os.Getenv("MONGOLAB_URI")
-	if uri == "" {
-		return &bot.OutgoingMessage{Text: "no connection string provided"}
-	}
-	DB = os.Getenv("MONGOLAB_DB")
-	if uri == "" {
-		return &bot.OutgoingMessage{Text: "no database provided"}
+// eventAttachments returns a location attachment for an event's venue, if the provider gave its coordinates, and an
+// image attachment for its picture, if it has one and Images can upload it.
+func (handler Handler) eventAttachments(v Event) []bot.Attachment {
+	var attachments []bot.Attachment
+	if v.Latitude != 0 || v.Longitude != 0 {
+		name := v.VenueName
+		if name == "" {
+			name = v.Title
+		}
+		attachments = append(attachments, bot.Attachment{
+			Type: "location",
+			Name: name,
+			Lat:  strconv.FormatFloat(v.Latitude, 'f', -1, 64),
+			Lng:  strconv.FormatFloat(v.Longitude, 'f', -1, 64),
+		})
 	}
-	sess, err := mgo.Dial(uri)
-	if err != nil {
-		return &bot.OutgoingMessage{Text: "can't connect to mongo"}
+	if v.ImageURL != "" && handler.Images.Enabled() {
+		img, err := handler.Images.Upload(v.ImageURL)
+		if err != nil {
+			fmt.Println("Couldn't upload event image:", err)
+		} else {
+			attachments = append(attachments, bot.Attachment{Type: "image", URL: img})
+		}
 	}
-	defer sess.Close()
-	col := sess.DB(DB).C("groupmeEventSearchesV1")
-	u := user{UserID: message.UserID}
-	var td eventSearch
-	col.Find(bson.M{"term": strings.ToLower(term)}).One(&td)
-	if len(td.Term) < 1 {
-		loc, _ := time.LoadLocation("America/New_York")
-		col.Insert(eventSearch{Term: strings.ToLower(term), LatestCreated: time.Date(1970, 1, 1, 0, 0, 0, 0, loc), Users: []user{u}})
-	} else {
-		col.Update(bson.M{"term": strings.ToLower(term)}, bson.M{"$addToSet": bson.M{"users": u}})
-	}
-	/* col.Upsert(bson.M{
-		"term":strings.ToLower(term),
-	}, bson.M{
-		"latest_created": time.Now(),
-		"$addToSet": bson.M{
-			"users": u,
-		},
-	}) */
-	return &bot.OutgoingMessage{Text: "New events for \"" + strings.ToLower(term) + "\" will now be tracked."}
+	return attachments
 }
 
-func (handler Handler) SetupSearch(botID string) {
-	key := handler.Key
-	if len(key) < 1 {
-		fmt.Println("Key is empty.")
-		return
+// FormatEvent renders an event as one line, with when it starts for people in loc, leaving out whatever the provider
+// didn't say.
+func FormatEvent(v Event, loc *time.Location) string {
+	text := describeStart(v, loc, time.Now()) + ": " + v.Title
+	switch {
+	case v.VenueName != "" && v.City != "":
+		text += " [at " + v.VenueName + " in " + v.City + "]"
+	case v.VenueName != "":
+		text += " [at " + v.VenueName + "]"
+	case v.City != "":
+		text += " [in " + v.City + "]"
 	}
-	zip := handler.ZIP
-	if len(zip) != 5 {
-		fmt.Println("ZIP is empty")
-		return
+	if v.Distance > 0 {
+		text += " (" + formatDistance(v.Distance) + ")"
 	}
-	radius := handler.Radius
-	if radius == 0 {
-		radius = 100
+	if v.URL != "" {
+		text += " " + v.URL
 	}
+	return text
+}
+
+// SetupSearch starts running groupID's tracked searches every ten minutes, announcing new events and posting digests
+// using botID. A bot can only post in the group it was added to, so botID has to be that group's.
+func (handler Handler) SetupSearch(botID, groupID string) {
 	ticker := time.NewTicker(10 * time.Minute)
 	quit := make(chan struct{})
 
 	go func(handler Handler, botID string) {
 		c := make(chan *bot.OutgoingMessage)
 		go monitorForMessages(c, botID)
-		uri := os.Getenv("MONGOLAB_URI")
-		if uri == "" {
-			fmt.Println("no connection string provided")
-			os.Exit(1)
-		}
-		DB = os.Getenv("MONGOLAB_DB")
-		if uri == "" {
-			fmt.Println("no database provided")
-			os.Exit(1)
-		}
-		sess, err := mgo.Dial(uri)
-		if err != nil {
-			fmt.Printf("Can't connect to mongo, go error %v\n", err)
-			os.Exit(1)
-		}
-		col := sess.DB(DB).C("groupmeEventSearchesV1")
-		var searches []eventSearch
-		err = col.Find(nil).All(&searches)
-		if err != nil {
-			fmt.Println(err)
-		}
-		key := handler.Key
-		client := eventful.New(key)
-		for _, v := range searches {
-			handler.recurringSearch(col, v, c, client)
-		}
-		sess.Close()
+		adoptSearches(groupID)
+		handler.runSearches(groupID, c)
 		for {
 			select {
 			case <-ticker.C:
-				sess, err := mgo.Dial(uri)
-				if err != nil {
-					fmt.Printf("Can't connect to mongo, go error %v\n", err)
-					os.Exit(1)
-				}
-				col := sess.DB(DB).C("groupmeEventSearchesV1")
-				var searches []eventSearch
-				err = col.Find(nil).All(&searches)
-				if err != nil {
-					fmt.Println(err)
-				}
-				key := handler.Key
-				client := eventful.New(key)
-				for _, v := range searches {
-					handler.recurringSearch(col, v, c, client)
-				}
-				sess.Close()
+				handler.runSearches(groupID, c)
 			case <-quit:
 				ticker.Stop()
 				return
@@ -234,6 +346,51 @@ func (handler Handler) SetupSearch(botID string) {
 	}(handler, botID)
 }
 
+// runSearches polls groupID's tracked searches and sends its due digests. When the database can't be reached it waits
+// for the next tick rather than giving up.
+func (handler Handler) runSearches(groupID string, c chan *bot.OutgoingMessage) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	handler.pollSearches(sess, groupID, c)
+	handler.sendDigests(sess, groupID, c)
+}
+
+// adoptSearches moves the searches saved before they belonged to a group into groupID, the only group the bot could
+// have been announcing them in. Nothing is moved when groupID is empty.
+func adoptSearches(groupID string) {
+	if groupID == "" {
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(searchesCollection)
+	_, err = col.UpdateAll(bson.M{"group_id": bson.M{"$exists": false}}, bson.M{"$set": bson.M{"group_id": groupID}})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// pollSearches runs every search tracked in groupID.
+func (handler Handler) pollSearches(sess *mgo.Session, groupID string, c chan *bot.OutgoingMessage) {
+	col := sess.DB(DB).C(searchesCollection)
+	var searches []eventSearch
+	err := col.Find(bson.M{"group_id": groupID}).All(&searches)
+	if err != nil {
+		fmt.Println(err)
+	}
+	for _, v := range searches {
+		handler.recurringSearch(col, v, c)
+	}
+}
+
 func monitorForMessages(c chan *bot.OutgoingMessage, botID string) {
 	for {
 		select {
@@ -256,72 +413,151 @@ func monitorForMessages(c chan *bot.OutgoingMessage, botID string) {
 	}
 }
 
-func (handler Handler) recurringSearch(col *mgo.Collection, search eventSearch, c chan *bot.OutgoingMessage, client *eventful.Client) {
-	zip := handler.ZIP
-	if len(zip) != 5 {
-		return
+func (handler Handler) recurringSearch(col *mgo.Collection, search eventSearch, c chan *bot.OutgoingMessage) {
+	location := search.Location
+	if location == "" {
+		location = handler.Location
 	}
-	radius := handler.Radius
+	radius := search.Radius
 	if radius == 0 {
-		radius = 100
+		radius = handler.Radius
 	}
-	sort := handler.SortOrder
-	if len(sort) < 1 {
-		sort = "date"
+	if radius == 0 {
+		radius = 100
 	}
+	loc := handler.GroupTimezone(search.GroupID)
 	start := time.Now()
-	end := start.AddDate(0, 0, 180)
-	dateString := fmt.Sprintf("%d%02d%02d00-%d%02d%02d00",
-		start.Year(), start.Month(), start.Day(), end.Year(), end.Month(), end.Day())
-	events, err := client.SearchAllEvents(search.Term, dateString, zip, radius, sort, 10)
-	if err != nil {
+	req := SearchRequest{
+		Term:     search.Term,
+		Location: location,
+		Radius:   radius,
+		Start:    start,
+		End:      start.AddDate(0, 0, 180),
+		Sort:     handler.SortOrder,
+		Timezone: loc.String(),
+	}
+	events := calendarEvents(groupCalendars(search.GroupID), req)
+	if handler.Provider != nil && location != "" {
+		found, err := handler.Provider.SearchAll(req, 10)
+		if err != nil {
+			return
+		}
+		events = append(events, found...)
+	}
+	fresh := newEvents(&search, events)
+	firstRun := search.Checked.IsZero()
+	search.Checked = time.Now()
+	if err := col.UpdateId(search.ID, search); err != nil {
+		fmt.Println(err)
+		return
+	}
+	if firstRun || len(fresh) == 0 {
+		// The events found the first time a search runs were already there when it was tracked.
 		return
 	}
-	var event eventful.Event
+	fmt.Printf("Found %d new events for %q\n", len(fresh), search.Term)
+	sortEvents(fresh, "", loc)
+	handler.queueDigest(col.Database.Session, search, fresh)
+	var immediate []user
+	for _, u := range search.Users {
+		if u.delivery() == deliverImmediate {
+			immediate = append(immediate, u)
+		}
+	}
+	if len(immediate) == 0 {
+		return
+	}
+	for _, m := range announcement(search, immediate, fresh, loc) {
+		c <- m
+	}
+}
+
+// newEvents returns the events a search hasn't announced before, remembering every one of them in search.Announced and
+// the newest creation time among them in search.LatestCreated.
+func newEvents(search *eventSearch, events []Event) []Event {
+	announced := make(map[string]bool, len(search.Announced))
+	for _, id := range search.Announced {
+		announced[id] = true
+		announced[seriesID(id)] = true
+	}
 	latest := search.LatestCreated
-	outtf := "1/2/2006 3:04pm"
-	found := false
-	var created time.Time
-	intf := "2006-01-02 15:04:05"
+	var fresh []Event
 	for _, v := range events {
-		created, _ = time.Parse(intf, v.Created)
-		if created.After(latest) {
-			latest = created
-			found = true
-			event = v
-		} else {
+		if v.ID == "" || announced[v.ID] {
+			continue
+		}
+		series := seriesID(v.ID)
+		repeat := announced[series]
+		announced[v.ID], announced[series] = true, true
+		if repeat || !v.Created.IsZero() && v.Created.Before(search.LatestCreated.Add(-createdSlack)) {
+			// Further occurrences of a repeating event, and long-listed events that have only now come into range, or
+			// back into it, aren't news.
+			search.Announced = append(search.Announced, v.ID)
+			continue
+		}
+		if v.Created.After(latest) {
+			latest = v.Created
 		}
+		search.Announced = append(search.Announced, v.ID)
+		fresh = append(fresh, v)
 	}
-	if found {
-		fmt.Println("Found an event!")
-		startTime, _ := time.Parse(intf, event.StartTime)
-		text := fmt.Sprintf("%s: %s [at %s in %s] %s",
-			startTime.Format(outtf),
-			event.Title,
-			event.VenueName,
-			event.CityName,
-			event.URL,
-		)
-		loci := make([][2]int, len(search.Users))
-		mentions := make([]int, len(search.Users))
-		for i, v := range search.Users {
-			mentions[i], _ = strconv.Atoi(v.UserID)
-			loci[i] = [2]int{
-				len(startTime.Format(outtf)) + 2,
-				len(event.Title),
-			}
+	if len(search.Announced) > maxAnnounced {
+		search.Announced = search.Announced[len(search.Announced)-maxAnnounced:]
+	}
+	search.LatestCreated = latest
+	return fresh
+}
+
+// announcement batches new events for a search into as few messages as fit, with times shown in loc, mentioning the
+// given users at the end of the first.
+func announcement(search eventSearch, subscribers []user, events []Event, loc *time.Location) []*bot.OutgoingMessage {
+	header := "New events for \"" + search.Term + "\""
+	if search.Location != "" {
+		header += " near " + search.Location
+	}
+	header += ":"
+	lines := make([]string, 0, len(events)+1)
+	for i, v := range events {
+		if i == maxAnnounce {
+			lines = append(lines, fmt.Sprintf("…and %d more. See them with \"!events %s\".", len(events)-i, search.Term))
+			break
 		}
-		search.LatestCreated = latest
-		col.Update(bson.M{"term": search.Term}, search)
-		c <- &bot.OutgoingMessage{
-			Text: text,
-			Attachments: []bot.Attachment{
-				bot.Attachment{
-					Loci:    loci,
-					Type:    "mentions",
-					UserIDs: mentions,
-				},
-			},
+		lines = append(lines, FormatEvent(v, loc))
+	}
+	users := make([]mentions.User, len(subscribers))
+	for i, v := range subscribers {
+		users[i] = mentions.User{ID: v.UserID, Name: v.Name}
+	}
+	tags := mentions.New("")
+	tags.MentionAll(users)
+	// The first message keeps room for the mentions.
+	limit := maxMessage - tags.Len() - 1
+	var texts []string
+	text := header
+	for _, line := range lines {
+		if len(text)+1+len(line) > limit && text != "" {
+			texts = append(texts, text)
+			text = ""
+			limit = maxMessage
+		}
+		if text != "" {
+			text += "\n"
+		}
+		text += line
+	}
+	texts = append(texts, text)
+	messages := make([]*bot.OutgoingMessage, len(texts))
+	for i, text := range texts {
+		if i > 0 {
+			messages[i] = &bot.OutgoingMessage{Text: text}
+			continue
+		}
+		first := mentions.New(text)
+		if len(users) > 0 {
+			first.WriteString("\n")
+			first.MentionAll(users)
 		}
+		messages[i] = first.Message()
 	}
+	return messages
 }
@@ -0,0 +1,220 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/groupme"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+)
+
+func TestNewEvents(t *testing.T) {
+	latest := time.Date(2026, 10, 19, 9, 0, 0, 0, time.UTC)
+	search := eventSearch{
+		Term:          "jazz",
+		LatestCreated: latest,
+		Announced:     []string{"old", "night@20261020T230000Z"},
+	}
+	fresh := newEvents(&search, []Event{
+		{ID: "old", Title: "Announced already"},
+		{ID: "night@20261027T230000Z", Title: "Next week's jazz night"},
+		{ID: "listed", Title: "Listed long ago", Created: latest.Add(-createdSlack - time.Hour)},
+		{ID: "late", Title: "Listed a little late", Created: latest.Add(-time.Hour)},
+		{ID: "new", Title: "Just listed", Created: latest.Add(2 * time.Hour)},
+		{ID: "newer", Title: "Listed after", Created: latest.Add(time.Hour)},
+		{ID: "undated", Title: "No creation time"},
+		{ID: "", Title: "No ID"},
+		{ID: "brunch@20261025T110000Z", Title: "Jazz brunch"},
+		{ID: "brunch@20261101T110000Z", Title: "Jazz brunch again"},
+		{ID: "new", Title: "Just listed, twice"},
+	})
+	var titles []string
+	for _, v := range fresh {
+		titles = append(titles, v.Title)
+	}
+	want := []string{"Listed a little late", "Just listed", "Listed after", "No creation time", "Jazz brunch"}
+	if !reflect.DeepEqual(titles, want) {
+		t.Errorf("fresh = %q, want %q", titles, want)
+	}
+	if !search.LatestCreated.Equal(latest.Add(2 * time.Hour)) {
+		t.Errorf("latest created = %v, want %v", search.LatestCreated, latest.Add(2*time.Hour))
+	}
+	// Everything seen is remembered, so that none of it is announced next time either.
+	wantAnnounced := []string{"old", "night@20261020T230000Z", "night@20261027T230000Z", "listed", "late", "new",
+		"newer", "undated", "brunch@20261025T110000Z", "brunch@20261101T110000Z"}
+	if !reflect.DeepEqual(search.Announced, wantAnnounced) {
+		t.Errorf("announced = %q, want %q", search.Announced, wantAnnounced)
+	}
+	if fresh := newEvents(&search, []Event{{ID: "new"}, {ID: "brunch@20261108T110000Z"}}); len(fresh) != 0 {
+		t.Errorf("second run found %+v, want nothing", fresh)
+	}
+
+	// Only the most recent IDs are kept.
+	search.Announced = nil
+	var many []Event
+	for i := 0; i < maxAnnounced+5; i++ {
+		many = append(many, Event{ID: fmt.Sprint(i)})
+	}
+	newEvents(&search, many)
+	if len(search.Announced) != maxAnnounced || search.Announced[0] != "5" {
+		t.Errorf("kept %d IDs starting at %q, want %d starting at 5", len(search.Announced), search.Announced[0],
+			maxAnnounced)
+	}
+}
+
+func TestAnnouncement(t *testing.T) {
+	now := time.Now()
+	search := eventSearch{Term: "jazz", Location: "Tampa"}
+	subscribers := []user{{UserID: "1", Name: "Ann"}, {UserID: "2", Name: "Bo"}}
+	events := []Event{
+		{Title: "Jazz night", Start: now.Add(time.Hour)},
+		{Title: "Jazz brunch", Start: now.Add(24 * time.Hour)},
+	}
+
+	messages := announcement(search, subscribers, events, time.UTC)
+	if len(messages) != 1 {
+		t.Fatalf("got %d messages, want 1", len(messages))
+	}
+	text := messages[0].Text
+	if !strings.HasPrefix(text, "New events for \"jazz\" near Tampa:\n") || !strings.HasSuffix(text, "\n@Ann @Bo") ||
+		strings.Count(text, "\n") != 3 {
+		t.Errorf("text = %q", text)
+	}
+	if len(messages[0].Attachments) != 1 || !reflect.DeepEqual(messages[0].Attachments[0].UserIDs, []int{1, 2}) {
+		t.Errorf("attachments = %+v, want mentions of 1 and 2", messages[0].Attachments)
+	}
+
+	// Long lists are batched, with the mentions on the first message only, and cut short at maxAnnounce.
+	events = nil
+	for i := 0; i < maxAnnounce+3; i++ {
+		events = append(events, Event{
+			Title: fmt.Sprintf("A jazz concert with a name long enough to fill a message quickly, number %d", i),
+			Start: now.Add(time.Duration(i+1) * time.Hour),
+		})
+	}
+	messages = announcement(search, subscribers, events, time.UTC)
+	if len(messages) < 2 {
+		t.Fatalf("got %d messages, want the announcement batched", len(messages))
+	}
+	for i, m := range messages {
+		if len(m.Text) > maxMessage {
+			t.Errorf("message %d is %d bytes, over %d", i, len(m.Text), maxMessage)
+		}
+		if i > 0 && (m.Attachments != nil || strings.Contains(m.Text, "@Ann")) {
+			t.Errorf("message %d mentions someone: %+v", i, m)
+		}
+	}
+	if !strings.HasSuffix(messages[0].Text, "\n@Ann @Bo") {
+		t.Errorf("first message doesn't end with the mentions: %q", messages[0].Text)
+	}
+	last := messages[len(messages)-1].Text
+	if !strings.HasSuffix(last, "…and 3 more. See them with \"!events jazz\".") {
+		t.Errorf("last message = %q", last)
+	}
+	lines := 0
+	for _, m := range messages {
+		lines += strings.Count(m.Text, "A jazz concert")
+	}
+	if lines != maxAnnounce {
+		t.Errorf("announced %d events, want %d", lines, maxAnnounce)
+	}
+}
+
+func TestOutputEvents(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			fmt.Fprint(w, `{"payload":{"picture_url":"https://i.groupme.com/poster.png"}}`)
+			return
+		}
+		w.Header().Set("Content-Type", "image/png")
+		fmt.Fprint(w, "\x89PNG")
+	}))
+	defer server.Close()
+	handler := Handler{
+		Attachments: true,
+		Images:      groupme.ImageService{URL: server.URL + "/pictures", Token: "secret", Client: server.Client()},
+	}
+	now := time.Now()
+	events := []Event{
+		{
+			Title:     "Jazz night",
+			VenueName: "The Ale House",
+			Start:     now.Add(time.Hour),
+			Latitude:  27.77,
+			Longitude: -82.64,
+			ImageURL:  server.URL + "/poster.png",
+		},
+		{Title: "Jazz brunch", Start: now.Add(24 * time.Hour), Latitude: 27.5, Longitude: -82.5},
+		{Title: "Jazz talk", Start: now.Add(48 * time.Hour)},
+	}
+
+	messages := handler.outputEvents(events, 4, time.UTC)
+	if len(messages) != 3 {
+		t.Fatalf("got %d messages, want one for each event", len(messages))
+	}
+	for i, m := range messages {
+		if prefix := fmt.Sprintf("%d. ", 4+i); !strings.HasPrefix(m.Text, prefix) {
+			t.Errorf("message %d = %q, want it numbered %q", i, m.Text, prefix)
+		}
+	}
+	want := []bot.Attachment{
+		{Type: "location", Name: "The Ale House", Lat: "27.77", Lng: "-82.64"},
+		{Type: "image", URL: "https://i.groupme.com/poster.png"},
+	}
+	if !reflect.DeepEqual(messages[0].Attachments, want) {
+		t.Errorf("first attachments = %+v, want %+v", messages[0].Attachments, want)
+	}
+	// Without a venue the location is named after the event.
+	want = []bot.Attachment{{Type: "location", Name: "Jazz brunch", Lat: "27.5", Lng: "-82.5"}}
+	if !reflect.DeepEqual(messages[1].Attachments, want) {
+		t.Errorf("second attachments = %+v, want %+v", messages[1].Attachments, want)
+	}
+	if messages[2].Attachments != nil {
+		t.Errorf("third attachments = %+v, want none", messages[2].Attachments)
+	}
+
+	handler.Attachments = false
+	for _, m := range handler.outputEvents(events, 1, time.UTC) {
+		if m.Attachments != nil {
+			t.Errorf("attachments = %+v with them turned off", m.Attachments)
+		}
+	}
+
+	// Past Condense, results are listed together without attachments, in as few messages as fit.
+	handler.Attachments, handler.Condense = true, 2
+	messages = handler.outputEvents(events, 1, time.UTC)
+	if len(messages) != 1 || strings.Count(messages[0].Text, "\n") != 2 || messages[0].Attachments != nil {
+		t.Errorf("condensed = %+v", messages)
+	}
+	var long []Event
+	for i := 0; i < 40; i++ {
+		long = append(long, Event{
+			Title: fmt.Sprintf("A jazz concert with a fairly long name, number %d", i),
+			Start: now.Add(time.Duration(i+1) * time.Hour),
+		})
+	}
+	messages = handler.outputEvents(long, 1, time.UTC)
+	if len(messages) < 2 {
+		t.Fatalf("got %d messages for 40 results, want them split", len(messages))
+	}
+	next := 1
+	for i, m := range messages {
+		if len(m.Text) > maxMessage {
+			t.Errorf("message %d is %d bytes, over %d", i, len(m.Text), maxMessage)
+		}
+		for _, line := range strings.Split(m.Text, "\n") {
+			if prefix := fmt.Sprintf("%d. ", next); !strings.HasPrefix(line, prefix) {
+				t.Errorf("message %d has %q, want it numbered %q", i, line, prefix)
+			}
+			next++
+		}
+	}
+	if next != 41 {
+		t.Errorf("listed %d results, want 40", next-1)
+	}
+}
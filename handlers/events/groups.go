@@ -0,0 +1,135 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// groupSettings holds a group's event search preferences.
+type groupSettings struct {
+	GroupID string `bson:"group_id"`
+	// Location is the place the group's searches are run around unless a search says otherwise
+	Location string `bson:"location"`
+	// Timezone is the IANA name of the zone date phrases like "tonight" are read in
+	Timezone string `bson:"timezone"`
+	// Calendars are links to iCalendar feeds searched alongside the provider
+	Calendars []string `bson:"calendars"`
+	// Home is the point distances to events are measured from, if the group has set one
+	Home *point `bson:"home,omitempty"`
+}
+
+const groupsCollection = "groupmeEventGroupsV1"
+
+// findGroup loads a group's settings. A group that hasn't saved any gets empty settings.
+func findGroup(sess *mgo.Session, groupID string) (groupSettings, error) {
+	var settings groupSettings
+	err := sess.DB(DB).C(groupsCollection).Find(bson.M{"group_id": groupID}).One(&settings)
+	if err == mgo.ErrNotFound {
+		return groupSettings{GroupID: groupID}, nil
+	}
+	return settings, err
+}
+
+// groupLocation returns the place a group's searches are run around: its own location if it has set one, or the
+// handler's default.
+func (handler Handler) groupLocation(groupID string) string {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return handler.Location
+	}
+	defer sess.Close()
+	settings, err := findGroup(sess, groupID)
+	if err != nil {
+		fmt.Println(err)
+	}
+	if settings.Location == "" {
+		return handler.Location
+	}
+	return settings.Location
+}
+
+// setLocation saves the place a group's searches are run around.
+func (handler Handler) setLocation(groupID, place string) *bot.OutgoingMessage {
+	if place == "" {
+		return &bot.OutgoingMessage{Text: "Event searches here are run around " + handler.groupLocation(groupID) +
+			". Change it with \"!events location <zip or city>\"."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	_, err = sess.DB(DB).C(groupsCollection).Upsert(bson.M{"group_id": groupID}, bson.M{
+		"$set": bson.M{"location": place},
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "Event searches here will now be run around " + place + "."}
+}
+
+// defaultTimezone is used when neither the group nor the handler names a time zone.
+const defaultTimezone = "America/New_York"
+
+// GroupTimezone returns the zone a group's date phrases are read in and its event times are shown in: its own if it
+// has set one, or the handler's default.
+func (handler Handler) GroupTimezone(groupID string) *time.Location {
+	name := handler.Timezone
+	if name == "" {
+		name = defaultTimezone
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+	} else {
+		defer sess.Close()
+		settings, err := findGroup(sess, groupID)
+		if err != nil {
+			fmt.Println(err)
+		}
+		if settings.Timezone != "" {
+			name = settings.Timezone
+		}
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		fmt.Println(err)
+		return time.UTC
+	}
+	return loc
+}
+
+// setTimezone saves the zone a group's date phrases are read in. The name is taken from the message as sent, since
+// zone names are case-sensitive.
+func (handler Handler) setTimezone(groupID, text string) *bot.OutgoingMessage {
+	var name string
+	if args := argsAfter(text, "timezone"); len(args) > 0 {
+		name = args[0]
+	}
+	if name == "" {
+		return &bot.OutgoingMessage{Text: "Dates in event searches here are read in " +
+			handler.GroupTimezone(groupID).String() + ". Change it with \"!events timezone <zone>\", e.g. America/Chicago."}
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		return &bot.OutgoingMessage{Text: "I don't know the time zone \"" + name + "\". Try a name like America/Chicago."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	_, err = sess.DB(DB).C(groupsCollection).Upsert(bson.M{"group_id": groupID}, bson.M{
+		"$set": bson.M{"timezone": loc.String()},
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "Dates in event searches here will now be read in " + loc.String() + "."}
+}
@@ -0,0 +1,159 @@
+package events
+
+import (
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+)
+
+// occurrenceTime is the layout of the start times that tell the occurrences of a repeating event apart in their IDs.
+const occurrenceTime = "20060102T150405Z"
+
+// ICSProvider searches the events of an iCalendar (.ics) calendar, with repeating events expanded into their
+// occurrences. Calendars don't say how far away their events are, so a request's location and radius are ignored.
+type ICSProvider struct {
+	URL string
+	// Timezone is the IANA name of the zone Get reads floating times in, which should be the zone of the group asking;
+	// UTC is used when it's blank. Searches read them in the request's zone.
+	Timezone string
+	// Client fetches the calendar; nil means matchers.DefaultClient
+	Client *matchers.Client
+}
+
+// calendar fetches and decodes the provider's calendar.
+func (p ICSProvider) calendar() (*matchers.Calendar, error) {
+	if p.Client == nil {
+		return matchers.RetrieveCalendar(p.URL)
+	}
+	return p.Client.RetrieveCalendar(p.URL)
+}
+
+// Search returns one page of the calendar's events matching a request, in date order.
+func (p ICSProvider) Search(req SearchRequest, page, perPage int) (Results, error) {
+	events, err := p.matching(req)
+	if err != nil {
+		return Results{}, err
+	}
+	if perPage < 1 {
+		perPage = 10
+	}
+	results := Results{Total: len(events), Page: page, PageCount: (len(events) + perPage - 1) / perPage}
+	if start := (page - 1) * perPage; start >= 0 && start < len(events) {
+		end := start + perPage
+		if end > len(events) {
+			end = len(events)
+		}
+		results.Events = events[start:end]
+	}
+	return results, nil
+}
+
+// SearchAll returns all of the calendar's events matching a request. The calendar is fetched once, so there are no
+// pages to limit.
+func (p ICSProvider) SearchAll(req SearchRequest, maxPages int) ([]Event, error) {
+	return p.matching(req)
+}
+
+// Get returns the calendar's event with the given ID: its UID, followed for an occurrence of a repeating event by "@"
+// and the occurrence's start. An event's bare UID gets its first occurrence.
+func (p ICSProvider) Get(id string) (Event, error) {
+	cal, err := p.calendar()
+	if err != nil {
+		return Event{}, err
+	}
+	loc := loadZone(p.Timezone, time.UTC)
+	uid := seriesID(id)
+	if uid != id {
+		start, _ := time.Parse(occurrenceTime, id[len(uid)+1:])
+		for _, v := range cal.Between(start, start.Add(time.Second)) {
+			if v.UID == uid {
+				return fromCalendar(v, loc), nil
+			}
+		}
+		return Event{}, errNoEvent
+	}
+	for _, v := range cal.Events {
+		if v.UID == uid && v.RecurrenceID.IsZero() {
+			return fromCalendar(v, loc), nil
+		}
+	}
+	return Event{}, errNoEvent
+}
+
+// seriesID returns the UID of the repeating event an occurrence's ID belongs to, or the ID itself for anything else.
+func seriesID(id string) string {
+	if at := strings.LastIndex(id, "@"); at >= 0 {
+		if _, err := time.Parse(occurrenceTime, id[at+1:]); err == nil {
+			return id[:at]
+		}
+	}
+	return id
+}
+
+// matching returns the calendar's events that match a request, in date order.
+func (p ICSProvider) matching(req SearchRequest) ([]Event, error) {
+	cal, err := p.calendar()
+	if err != nil {
+		return nil, err
+	}
+	start, end := req.Start, req.End
+	if start.IsZero() {
+		start = time.Now()
+	}
+	if end.IsZero() {
+		end = start.AddDate(1, 0, 0)
+	}
+	// The calendar reads all-day and floating times as UTC, so its window is widened by a day each way and the events
+	// are checked against the request's once they're in its zone.
+	zone := req.zone()
+	var events []Event
+	for _, v := range cal.Between(start.AddDate(0, 0, -1), end.AddDate(0, 0, 1)) {
+		if !matchesTerm(req.Term, v) {
+			continue
+		}
+		event := fromCalendar(v, zone)
+		if begins := event.StartIn(zone); !begins.Before(start) && begins.Before(end) {
+			events = append(events, event)
+		}
+	}
+	sortEvents(events, "", zone)
+	return events, nil
+}
+
+// matchesTerm reports whether every word of a search term appears in a calendar event's summary, description,
+// location or categories.
+func matchesTerm(term string, v matchers.CalendarEvent) bool {
+	text := strings.ToLower(v.Summary + " " + v.Description + " " + v.Location + " " + strings.Join(v.Categories, " "))
+	for _, word := range strings.Fields(strings.ToLower(term)) {
+		if !strings.Contains(text, word) {
+			return false
+		}
+	}
+	return true
+}
+
+// fromCalendar converts a calendar event. Calendars give a free-form location, which is used as the venue. Floating
+// times are read in loc.
+func fromCalendar(v matchers.CalendarEvent, loc *time.Location) Event {
+	id := v.UID
+	if v.Recurrence != nil || !v.RecurrenceID.IsZero() {
+		// Each occurrence of a repeating event needs its own ID.
+		id += "@" + v.Start.UTC().Format(occurrenceTime)
+	}
+	start, end := v.Start, v.End
+	if v.Floating {
+		start, end = wallClock(start, loc), wallClock(end, loc)
+	}
+	return Event{
+		ID:          id,
+		Title:       v.Summary,
+		URL:         v.URL,
+		Description: v.Description,
+		VenueName:   v.Location,
+		Start:       start,
+		End:         end,
+		AllDay:      v.AllDay,
+		Created:     v.Created,
+	}
+}
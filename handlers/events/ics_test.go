@@ -0,0 +1,170 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+)
+
+// icsStandIn serves a calendar with a weekly jazz night, a one-off jazz brunch in floating time and an unrelated
+// event.
+func icsStandIn() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/calendar")
+		fmt.Fprint(w, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"+
+			"BEGIN:VEVENT\r\nUID:night\r\nSUMMARY:Jazz night\r\nDTSTART:20261020T230000Z\r\n"+
+			"DTEND:20261021T010000Z\r\nRRULE:FREQ=WEEKLY;COUNT=3\r\nEND:VEVENT\r\n"+
+			"BEGIN:VEVENT\r\nUID:brunch\r\nSUMMARY:Jazz brunch\r\nDTSTART:20261025T110000\r\nEND:VEVENT\r\n"+
+			"BEGIN:VEVENT\r\nUID:quiz\r\nSUMMARY:Quiz\r\nDTSTART:20261022T230000Z\r\nEND:VEVENT\r\n"+
+			"END:VCALENDAR\r\n")
+	}))
+}
+
+// standInClient creates a client that reaches server, which the default client refuses for being on loopback.
+func standInClient(server *httptest.Server) *matchers.Client {
+	c := matchers.NewClient()
+	c.HTTPClient = server.Client()
+	return c
+}
+
+func TestICSSearch(t *testing.T) {
+	server := icsStandIn()
+	defer server.Close()
+	p := ICSProvider{URL: server.URL, Client: standInClient(server)}
+	req := SearchRequest{
+		Term:     "jazz",
+		Start:    time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC),
+		End:      time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC),
+		Timezone: "America/Chicago",
+	}
+
+	res, err := p.Search(req, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 4 || res.PageCount != 2 || len(res.Events) != 2 {
+		t.Fatalf("page 1 = %+v", res)
+	}
+	if res.Events[0].ID != "night@20261020T230000Z" {
+		t.Errorf("first occurrence ID = %q", res.Events[0].ID)
+	}
+	res, err = p.Search(req, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(res.Events) != 2 {
+		t.Fatalf("page 2 = %+v", res)
+	}
+	// The brunch's floating time is read in the request's zone.
+	all, err := p.SearchAll(req, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	chicago, _ := time.LoadLocation("America/Chicago")
+	var brunch Event
+	for _, v := range all {
+		if v.ID == "brunch" {
+			brunch = v
+		}
+	}
+	if want := time.Date(2026, 10, 25, 11, 0, 0, 0, chicago); !brunch.Start.Equal(want) {
+		t.Errorf("floating start = %v, want %v", brunch.Start, want)
+	}
+}
+
+func TestICSGet(t *testing.T) {
+	server := icsStandIn()
+	defer server.Close()
+	p := ICSProvider{URL: server.URL, Client: standInClient(server)}
+
+	v, err := p.Get("night@20261027T230000Z")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 27, 23, 0, 0, 0, time.UTC); v.Title != "Jazz night" || !v.Start.Equal(want) {
+		t.Errorf("occurrence = %v at %v, want Jazz night at %v", v.Title, v.Start, want)
+	}
+	if _, err := p.Get("night@20261028T230000Z"); err != errNoEvent {
+		t.Errorf("Get(missing occurrence) error = %v, want errNoEvent", err)
+	}
+	if _, err := p.Get("missing"); err != errNoEvent {
+		t.Errorf("Get(missing) error = %v, want errNoEvent", err)
+	}
+
+	// Floating times are read in the provider's zone, as searches read them in the request's.
+	p.Timezone = "America/Chicago"
+	v, err = p.Get("brunch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	chicago, _ := time.LoadLocation("America/Chicago")
+	if want := time.Date(2026, 10, 25, 11, 0, 0, 0, chicago); !v.Start.Equal(want) {
+		t.Errorf("floating start = %v, want %v", v.Start, want)
+	}
+}
+
+func TestICSRefusesPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback calendar was requested")
+	}))
+	defer server.Close()
+	if _, err := (ICSProvider{URL: server.URL}).SearchAll(SearchRequest{Term: "jazz"}, 0); err == nil {
+		t.Error("searching a loopback calendar succeeded, want an error")
+	}
+	if _, err := (ICSProvider{URL: server.URL}).Get("night"); err == nil {
+		t.Error("getting an event from a loopback calendar succeeded, want an error")
+	}
+	// The calendar is read before anything is saved, so a refused one never reaches the database.
+	if m := addCalendar("123", server.URL); !strings.HasPrefix(m.Text, "Couldn't read a calendar") {
+		t.Errorf("adding a loopback calendar = %+v, want it refused", m)
+	}
+}
+
+func TestICSSearchAllDay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"+
+			"BEGIN:VEVENT\r\nUID:fair\r\nSUMMARY:Jazz fair\r\nDTSTART;VALUE=DATE:20261024\r\nEND:VEVENT\r\n"+
+			"BEGIN:VEVENT\r\nUID:late\r\nSUMMARY:Late jazz\r\nDTSTART:20261024T020000Z\r\nEND:VEVENT\r\n"+
+			"END:VCALENDAR\r\n")
+	}))
+	defer server.Close()
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Saturday in Chicago starts at 5am UTC, after the fair's midnight UTC start and the late set's 2am.
+	req := SearchRequest{
+		Term:     "jazz",
+		Start:    time.Date(2026, 10, 24, 0, 0, 0, 0, chicago),
+		End:      time.Date(2026, 10, 25, 0, 0, 0, 0, chicago),
+		Timezone: "America/Chicago",
+	}
+	events, err := ICSProvider{URL: server.URL, Client: standInClient(server)}.SearchAll(req, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].ID != "fair" {
+		t.Fatalf("events = %+v, want just the fair", events)
+	}
+	if want := time.Date(2026, 10, 24, 0, 0, 0, 0, chicago); !events[0].StartIn(chicago).Equal(want) {
+		t.Errorf("fair starts %v in Chicago, want %v", events[0].StartIn(chicago), want)
+	}
+}
+
+func TestSeriesID(t *testing.T) {
+	for id, want := range map[string]string{
+		"night@20261027T230000Z":             "night",
+		"abc123@google.com@20261027T230000Z": "abc123@google.com",
+		"abc123@google.com":                  "abc123@google.com",
+		"E0-001-123456789-0":                 "E0-001-123456789-0",
+	} {
+		if got := seriesID(id); got != want {
+			t.Errorf("seriesID(%q) = %q, want %q", id, got, want)
+		}
+	}
+}
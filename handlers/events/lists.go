@@ -0,0 +1,95 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const listsCollection = "groupmeEventListsV1"
+
+// postedList is the last numbered list of events posted in a group, so that other commands can refer to its events by
+// number.
+type postedList struct {
+	GroupID string    `bson:"group_id"`
+	Events  []Event   `bson:"events"`
+	Posted  time.Time `bson:"posted"`
+}
+
+// saveList remembers the events just posted in a group, in the order they were numbered.
+func saveList(groupID string, events []Event) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	_, err = sess.DB(DB).C(listsCollection).Upsert(bson.M{"group_id": groupID}, postedList{
+		GroupID: groupID,
+		Events:  events,
+		Posted:  time.Now(),
+	})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// LastList returns the last numbered list of events posted in a group by "!events", first event first.
+func LastList(groupID string) ([]Event, error) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+	var list postedList
+	err = sess.DB(DB).C(listsCollection).Find(bson.M{"group_id": groupID}).One(&list)
+	if err == mgo.ErrNotFound {
+		return nil, nil
+	}
+	return list.Events, err
+}
+
+// FindEvent looks up an event mentioned in a group, either by its number in the last list posted there ("2") or by
+// its ID. IDs are checked against that list, then the group's calendars, then the handler's provider.
+func (handler Handler) FindEvent(groupID, ref string) (Event, error) {
+	listed, err := LastList(groupID)
+	if err != nil {
+		return Event{}, err
+	}
+	if n, err := strconv.Atoi(ref); err == nil && n > 0 && n < 1000 {
+		if n > len(listed) {
+			return Event{}, fmt.Errorf("There's no event %d in the last list of events posted here.", n)
+		}
+		return listed[n-1], nil
+	}
+	for _, v := range listed {
+		if v.ID == ref {
+			return v, nil
+		}
+	}
+	zone := handler.GroupTimezone(groupID).String()
+	for _, link := range groupCalendars(groupID) {
+		if v, err := (ICSProvider{URL: link, Timezone: zone}).Get(ref); err == nil {
+			return v, nil
+		}
+	}
+	provider := handler.Provider
+	if provider == nil {
+		return Event{}, errNoEvent
+	}
+	if calendar, ok := provider.(ICSProvider); ok {
+		// Floating times are read in the group's zone, as they are when the group searches.
+		calendar.Timezone = zone
+		provider = calendar
+	}
+	v, err := provider.Get(ref)
+	if err == errNoEvent {
+		return Event{}, errors.New("No event found with the ID " + ref + ".")
+	}
+	return v, err
+}
@@ -0,0 +1,238 @@
+package events
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const (
+	cursorsCollection = "groupmeEventCursorsV1"
+	// pageSize is the number of results shown at a time.
+	pageSize = 10
+	// distancePages is the number of provider pages gathered before sorting by distance, so that the nearest events
+	// come first across more than one page.
+	distancePages = 4
+	// emptyPages is the number of provider pages "!events more" goes through looking for results inside the radius
+	// before giving up.
+	emptyPages = 5
+)
+
+// Narrowing is how a search around the group's location that finds too many events is narrowed down. Searches that
+// name their own distance with "within" are never narrowed.
+type Narrowing struct {
+	// Above is the number of results above which a search is narrowed; zero turns narrowing off
+	Above int
+	// Radius is the distance in miles a narrowed search is run at again
+	Radius int
+}
+
+// NewNarrowing sets up a Narrowing from the EVENTS_NARROW_ABOVE and EVENTS_NARROW_RADIUS environment variables. Unset,
+// searches with more than 10 results are narrowed to 25 miles; EVENTS_NARROW_ABOVE=0 turns narrowing off.
+func NewNarrowing() (Narrowing, error) {
+	narrow := Narrowing{Above: 10, Radius: 25}
+	for name, n := range map[string]*int{"EVENTS_NARROW_ABOVE": &narrow.Above, "EVENTS_NARROW_RADIUS": &narrow.Radius} {
+		value := os.Getenv(name)
+		if value == "" {
+			continue
+		}
+		v, err := strconv.Atoi(value)
+		if err != nil || v < 0 {
+			return Narrowing{}, fmt.Errorf("%s should be a whole number of at least 0, not %q", name, value)
+		}
+		*n = v
+	}
+	return narrow, nil
+}
+
+// searchCursor is a user's last search in a group and how far through its results they've got, for "!events more".
+type searchCursor struct {
+	GroupID string        `bson:"group_id"`
+	UserID  string        `bson:"user_id"`
+	Request SearchRequest `bson:"request"`
+	Label   string        `bson:"label"`
+	Sort    string        `bson:"sort"`
+	// Home is where results are measured from; nil means they aren't
+	Home *point `bson:"home,omitempty"`
+	// Filter drops results farther than the radius from Home, which is only set when Home is where the search is run
+	Filter bool `bson:"filter"`
+	// Events are the results gathered so far, in the order they're shown
+	Events []Event `bson:"events"`
+	// Shown is how many of Events have been posted
+	Shown int `bson:"shown"`
+	// Fetched is how many pages the provider has given, out of its PageCount, and Total is its count of results
+	Fetched   int       `bson:"fetched"`
+	PageCount int       `bson:"page_count"`
+	Total     int       `bson:"total"`
+	Saved     time.Time `bson:"saved"`
+}
+
+// exhausted reports whether the provider has no more pages to give.
+func (cur searchCursor) exhausted(provider EventProvider) bool {
+	return provider == nil || cur.Fetched > 0 && cur.Fetched >= cur.PageCount
+}
+
+// total returns how many results the search has, and whether that's exact: results the provider hasn't given yet may
+// turn out to be outside the radius.
+func (cur searchCursor) total(provider EventProvider) (int, bool) {
+	if cur.exhausted(provider) {
+		return len(cur.Events), true
+	}
+	remaining := cur.Total - cur.Fetched*searchLimit
+	if remaining < 0 {
+		remaining = 0
+	}
+	return len(cur.Events) + remaining, !cur.Filter
+}
+
+// fetch adds the provider's next page of results to the cursor, or its first few when sorting by distance.
+func (handler Handler) fetch(cur *searchCursor) error {
+	if cur.exhausted(handler.Provider) {
+		return nil
+	}
+	pages := 1
+	if cur.Sort == sortDistance && cur.Fetched == 0 {
+		pages = distancePages
+	}
+	var found []Event
+	for i := 0; i < pages && !cur.exhausted(handler.Provider); i++ {
+		res, err := handler.Provider.Search(cur.Request, cur.Fetched+1, searchLimit)
+		if err != nil {
+			return err
+		}
+		cur.Fetched++
+		cur.PageCount = res.PageCount
+		cur.Total = res.Total
+		found = append(found, res.Events...)
+	}
+	cur.add(found)
+	return nil
+}
+
+// add measures newly found events from the cursor's home, if it has one, filters them by radius if it should, and
+// sorts them in among the results that haven't been shown yet.
+func (cur *searchCursor) add(events []Event) {
+	if cur.Home != nil {
+		measure(events, *cur.Home)
+		if cur.Filter {
+			events = withinRadius(events, cur.Request.Radius)
+		}
+	}
+	cur.Events = append(cur.Events, events...)
+	sortEvents(cur.Events[cur.Shown:], cur.Sort, cur.Request.zone())
+}
+
+// search gathers the first results for a cursor: the group's calendar events along with the provider's first page.
+func (handler Handler) search(cur *searchCursor, links []string) error {
+	if err := handler.fetch(cur); err != nil {
+		return err
+	}
+	cur.add(calendarEvents(links, cur.Request))
+	return nil
+}
+
+// narrow runs a search again at the handler's narrower radius if it found too many results and didn't name its own
+// distance, returning a note saying so. The wider results are kept when the narrower search finds nothing.
+func (handler Handler) narrow(cur *searchCursor, q query, links []string) (string, error) {
+	policy := handler.Narrow
+	if policy.Above <= 0 || policy.Radius <= 0 || q.Radius != 0 || policy.Radius >= cur.Request.Radius {
+		return "", nil
+	}
+	wide, _ := cur.total(handler.Provider)
+	radius := cur.Request.Radius
+	if wide <= policy.Above {
+		return "", nil
+	}
+	narrowed := *cur
+	narrowed.Request.Radius = policy.Radius
+	narrowed.Events, narrowed.Fetched, narrowed.PageCount, narrowed.Total = nil, 0, 0, 0
+	if err := handler.search(&narrowed, links); err != nil {
+		return "", err
+	}
+	if len(narrowed.Events) == 0 {
+		return "", nil
+	}
+	*cur = narrowed
+	return fmt.Sprintf("There are %d events within %d mi, so these are the ones within %d mi. "+
+		"Search \"%s within %dmi\" to see them all.", wide, radius, policy.Radius, cur.Request.Term, radius), nil
+}
+
+// page posts the cursor's next page of results, fetching more from the provider if they've all been shown, and
+// remembers them as the group's last list so they can be referred to by number. It returns nothing when there are no
+// more results.
+func (handler Handler) page(cur *searchCursor, note string) ([]*bot.OutgoingMessage, error) {
+	for tries := 0; cur.Shown >= len(cur.Events) && !cur.exhausted(handler.Provider) && tries < emptyPages; tries++ {
+		if err := handler.fetch(cur); err != nil {
+			return nil, err
+		}
+	}
+	first := cur.Shown
+	if first >= len(cur.Events) {
+		return nil, nil
+	}
+	end := first + pageSize
+	if end > len(cur.Events) {
+		end = len(cur.Events)
+	}
+	cur.Shown = end
+	total, exact := cur.total(handler.Provider)
+	of := fmt.Sprintf("%d", total)
+	if !exact {
+		of = "about " + of
+	}
+	header := fmt.Sprintf("Events for \"%s\" near %s %s, showing %d–%d of %s:",
+		cur.Request.Term, cur.Request.Location, cur.Label, first+1, end, of)
+	if note != "" {
+		header = note + "\n" + header
+	}
+	messages := []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: header}}
+	messages = append(messages, handler.outputEvents(cur.Events[first:end], first+1, handler.GroupTimezone(cur.GroupID))...)
+	footer := "Hear about new ones with \"!events track " + cur.Request.Term + "\", or get a reminder with \"!remind <number>\"."
+	if end < total {
+		footer = "See more with \"!events more\". " + footer
+	}
+	messages = append(messages, &bot.OutgoingMessage{Text: footer})
+	saveList(cur.GroupID, cur.Events[:end])
+	return messages, nil
+}
+
+// more posts the next page of the sender's last search in the group.
+func (handler Handler) more(message bot.IncomingMessage) []*bot.OutgoingMessage {
+	sess, err := handlers.Dial()
+	if err != nil {
+		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+	}
+	defer sess.Close()
+	var cur searchCursor
+	err = sess.DB(DB).C(cursorsCollection).Find(bson.M{"group_id": message.GroupID, "user_id": message.UserID}).One(&cur)
+	if err == mgo.ErrNotFound {
+		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "Search for something first, e.g. \"!events jazz\"."}}
+	}
+	if err != nil {
+		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+	}
+	messages, err := handler.page(&cur, "")
+	if err != nil {
+		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+	}
+	if messages == nil {
+		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "That's all of the events for \"" + cur.Request.Term + "\"."}}
+	}
+	saveCursor(sess, cur)
+	return messages
+}
+
+// saveCursor remembers a user's search and how far through it they've got, replacing their last one in the group.
+func saveCursor(sess *mgo.Session, cur searchCursor) {
+	cur.Saved = time.Now()
+	_, err := sess.DB(DB).C(cursorsCollection).Upsert(bson.M{"group_id": cur.GroupID, "user_id": cur.UserID}, cur)
+	if err != nil {
+		fmt.Println(err)
+	}
+}
@@ -0,0 +1,60 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCursorAdd(t *testing.T) {
+	day := func(d int) Event {
+		return Event{ID: string(rune('a' + d)), Start: time.Date(2026, 10, d, 19, 0, 0, 0, time.UTC)}
+	}
+	cur := searchCursor{Request: SearchRequest{Timezone: "UTC"}}
+	cur.add([]Event{day(5), day(1), day(9)})
+	cur.Shown = 2
+	// Events found later go in among the ones not shown yet, and the ones already shown stay put.
+	cur.add([]Event{day(7), day(3)})
+	var got string
+	for _, v := range cur.Events {
+		got += v.ID
+	}
+	if want := "bfdhj"; got != want {
+		t.Errorf("order = %s, want %s", got, want)
+	}
+}
+
+func TestNewNarrowing(t *testing.T) {
+	t.Setenv("EVENTS_NARROW_ABOVE", "")
+	t.Setenv("EVENTS_NARROW_RADIUS", "")
+	if narrow, err := NewNarrowing(); err != nil || narrow != (Narrowing{Above: 10, Radius: 25}) {
+		t.Errorf("default = %+v, %v", narrow, err)
+	}
+	t.Setenv("EVENTS_NARROW_ABOVE", "0")
+	t.Setenv("EVENTS_NARROW_RADIUS", "10")
+	if narrow, err := NewNarrowing(); err != nil || narrow != (Narrowing{Above: 0, Radius: 10}) {
+		t.Errorf("configured = %+v, %v", narrow, err)
+	}
+	t.Setenv("EVENTS_NARROW_RADIUS", "ten")
+	if _, err := NewNarrowing(); err == nil {
+		t.Error("EVENTS_NARROW_RADIUS=ten: want an error")
+	}
+}
+
+func TestCursorAddFilter(t *testing.T) {
+	home := point{Latitude: 27.77, Longitude: -82.64}
+	// Orlando is about 80 miles from St. Petersburg.
+	far := []Event{{ID: "orlando", Latitude: 28.54, Longitude: -81.38}}
+
+	cur := searchCursor{Request: SearchRequest{Location: "33701", Radius: 25}, Home: &home}
+	cur.add(append([]Event{}, far...))
+	if len(cur.Events) != 1 || cur.Events[0].Distance < 70 {
+		t.Errorf("searched around the location: events = %+v, want Orlando measured and kept", cur.Events)
+	}
+
+	cur = searchCursor{Request: SearchRequest{Location: home.String(), Radius: 25}, Home: &home}
+	cur.Filter = isCentre(cur.Request.Location, home)
+	cur.add(append([]Event{}, far...))
+	if len(cur.Events) != 0 {
+		t.Errorf("searched around home: events = %+v, want Orlando left out", cur.Events)
+	}
+}
@@ -0,0 +1,167 @@
+package events
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+type (
+	// Event is an event found by an EventProvider, in the same shape whichever provider found it.
+	Event struct {
+		// ID identifies the event to the provider that found it
+		ID          string
+		Title       string
+		URL         string
+		Description string
+		VenueName   string
+		City        string
+		Start       time.Time
+		End         time.Time
+		// AllDay is set for events given a date but no time. They start at midnight UTC on their first day, so that
+		// the date reads the same in every zone.
+		AllDay bool
+		// Created is when the provider listed the event, if it says
+		Created   time.Time
+		ImageURL  string
+		Latitude  float64
+		Longitude float64
+		// Distance is how many miles the venue is from the group's home, worked out when a search is run; zero means
+		// it isn't known
+		Distance float64
+	}
+
+	// SearchRequest is what an EventProvider searches for.
+	SearchRequest struct {
+		Term string
+		// Location is a ZIP code or a place name like "St. Petersburg, FL"
+		Location string
+		// Radius is in miles
+		Radius int
+		// Start and End bound when the events happen
+		Start time.Time
+		End   time.Time
+		// Sort is the provider's name for the order to return events in; blank means by date
+		Sort string
+		// Timezone is the IANA name of the zone of the area searched, which times given without a zone are read in
+		// when the venue's own isn't known; blank means UTC
+		Timezone string
+	}
+
+	// Results is one page of events found by an EventProvider.
+	Results struct {
+		Events []Event
+		// Total is the number of events found across all pages
+		Total int
+		// Page counts from 1
+		Page      int
+		PageCount int
+	}
+
+	// EventProvider searches a source of events, like an events API or a calendar.
+	EventProvider interface {
+		// Search returns one page of the events matching a request. Pages count from 1.
+		Search(req SearchRequest, page, perPage int) (Results, error)
+		// Get returns the event with the given ID.
+		Get(id string) (Event, error)
+		// SearchAll returns the events matching a request from up to maxPages pages.
+		SearchAll(req SearchRequest, maxPages int) ([]Event, error)
+	}
+)
+
+// zone returns the zone times without one are read in for a request.
+func (req SearchRequest) zone() *time.Location {
+	return loadZone(req.Timezone, time.UTC)
+}
+
+// loadZone returns the zone with the given IANA name, or fallback when the name is blank or unknown.
+func loadZone(name string, fallback *time.Location) *time.Location {
+	if name == "" {
+		return fallback
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		fmt.Println(err)
+		return fallback
+	}
+	return loc
+}
+
+// wallClock returns the time with the same date and clock reading as t in loc, for times that were read without a
+// zone.
+func wallClock(t time.Time, loc *time.Location) time.Time {
+	if t.IsZero() {
+		return t
+	}
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), loc)
+}
+
+// StartIn returns when an event starts for people in loc: its start, or for an all-day event, midnight in loc on its
+// first day.
+func (v Event) StartIn(loc *time.Location) time.Time {
+	if !v.AllDay {
+		return v.Start
+	}
+	start := v.Start.UTC()
+	return time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, loc)
+}
+
+// EndIn returns when an event ends for people in loc, converted the way StartIn converts its start. It's zero when
+// the provider doesn't say.
+func (v Event) EndIn(loc *time.Location) time.Time {
+	if !v.AllDay || v.End.IsZero() {
+		return v.End
+	}
+	end := v.End.UTC()
+	return time.Date(end.Year(), end.Month(), end.Day(), 0, 0, 0, 0, loc)
+}
+
+// errNoEvent is returned by Get when a provider has no event with the ID asked for.
+var errNoEvent = errors.New("No event found with that ID")
+
+// NewProvider creates the EventProvider with the given name, configured from the environment:
+//
+//	eventful      EVENTFUL_API_KEY, and optionally EVENTFUL_API_URL (also used when name is blank)
+//	ticketmaster  TICKETMASTER_API_KEY, and optionally TICKETMASTER_API_URL
+//	ics           EVENTS_ICS_URL
+func NewProvider(name string) (EventProvider, error) {
+	switch strings.ToLower(name) {
+	case "", "eventful":
+		key := os.Getenv("EVENTFUL_API_KEY")
+		if key == "" {
+			return nil, errors.New("EVENTFUL_API_KEY is not set")
+		}
+		return EventfulProvider{Key: key, URL: os.Getenv("EVENTFUL_API_URL")}, nil
+	case "ticketmaster":
+		key := os.Getenv("TICKETMASTER_API_KEY")
+		if key == "" {
+			return nil, errors.New("TICKETMASTER_API_KEY is not set")
+		}
+		return TicketmasterProvider{Key: key, URL: os.Getenv("TICKETMASTER_API_URL")}, nil
+	case "ics":
+		link := os.Getenv("EVENTS_ICS_URL")
+		if link == "" {
+			return nil, errors.New("EVENTS_ICS_URL is not set")
+		}
+		return ICSProvider{URL: link}, nil
+	}
+	return nil, fmt.Errorf("unknown events provider %q", name)
+}
+
+// searchAllPages runs a search a page at a time until it runs out of pages or reaches maxPages.
+func searchAllPages(provider EventProvider, req SearchRequest, perPage, maxPages int) ([]Event, error) {
+	var events []Event
+	for page := 1; page <= maxPages; page++ {
+		res, err := provider.Search(req, page, perPage)
+		if err != nil {
+			return events, err
+		}
+		events = append(events, res.Events...)
+		if page >= res.PageCount {
+			break
+		}
+	}
+	return events, nil
+}
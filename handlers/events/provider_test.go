@@ -0,0 +1,36 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStartAndEndIn(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip(err)
+	}
+	fair := Event{
+		Start:  time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2026, 10, 26, 0, 0, 0, 0, time.UTC),
+		AllDay: true,
+	}
+	if got, want := fair.StartIn(chicago), time.Date(2026, 10, 24, 0, 0, 0, 0, chicago); !got.Equal(want) {
+		t.Errorf("all-day start = %v, want %v", got, want)
+	}
+	if got, want := fair.EndIn(chicago), time.Date(2026, 10, 26, 0, 0, 0, 0, chicago); !got.Equal(want) {
+		t.Errorf("all-day end = %v, want %v", got, want)
+	}
+	fair.End = time.Time{}
+	if got := fair.EndIn(chicago); !got.IsZero() {
+		t.Errorf("missing all-day end = %v, want zero", got)
+	}
+
+	night := Event{
+		Start: time.Date(2026, 10, 24, 1, 0, 0, 0, time.UTC),
+		End:   time.Date(2026, 10, 24, 3, 0, 0, 0, time.UTC),
+	}
+	if !night.StartIn(chicago).Equal(night.Start) || !night.EndIn(chicago).Equal(night.End) {
+		t.Errorf("timed event moved: %v to %v", night.StartIn(chicago), night.EndIn(chicago))
+	}
+}
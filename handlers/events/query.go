@@ -0,0 +1,85 @@
+package events
+
+import (
+	"strconv"
+	"strings"
+)
+
+// query is a parsed "!events" search, like "jazz near tampa, fl within 25mi".
+type query struct {
+	Term string
+	// Location is the place given after "near", if any
+	Location string
+	// Radius is the distance in miles given after "within", if any
+	Radius int
+	// When is the date phrase given, like "this weekend" or "10/20-10/25", if any
+	When string
+	// Sort is the order given with "sort:", "date" or "distance", if any
+	Sort string
+}
+
+// parseQuery splits an event search into the search term and its "near <place>", "within <N>mi", "sort:distance" and
+// date modifiers, like "tonight", "next week", "in december" or "10/20-10/25". The modifiers can come in any order, but
+// follow the term.
+func parseQuery(text string) query {
+	var q query
+	words := strings.Fields(text)
+	terms := make([]string, 0, len(words))
+	var place []string
+	inPlace := false
+	for i := 0; i < len(words); i++ {
+		if when, used := whenPhrase(words[i:]); used > 0 && (len(terms) > 0 || inPlace) {
+			q.When = when
+			i += used - 1
+			inPlace = false
+			continue
+		}
+		switch words[i] {
+		case "sort:date", "sort:" + sortDistance:
+			q.Sort = strings.TrimPrefix(words[i], "sort:")
+			inPlace = false
+			continue
+		case "near":
+			if i+1 < len(words) {
+				inPlace = true
+				continue
+			}
+		case "within":
+			if radius, used := parseRadius(words[i+1:]); used > 0 {
+				q.Radius = radius
+				i += used
+				inPlace = false
+				continue
+			}
+		}
+		if inPlace {
+			place = append(place, words[i])
+		} else {
+			terms = append(terms, words[i])
+		}
+	}
+	q.Term = strings.Join(terms, " ")
+	q.Location = strings.Join(place, " ")
+	return q
+}
+
+// parseRadius reads a distance like "25mi", "25 mi", "25 miles" or just "25" from the start of words, returning the
+// number of miles and how many words it took up.
+func parseRadius(words []string) (int, int) {
+	if len(words) < 1 {
+		return 0, 0
+	}
+	number := strings.TrimSuffix(strings.TrimSuffix(strings.TrimSuffix(words[0], "miles"), "mile"), "mi")
+	radius, err := strconv.Atoi(number)
+	if err != nil || radius < 1 {
+		return 0, 0
+	}
+	used := 1
+	if number == words[0] && len(words) > 1 {
+		switch words[1] {
+		case "mi", "mile", "miles":
+			used++
+		}
+	}
+	return radius, used
+}
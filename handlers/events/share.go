@@ -0,0 +1,51 @@
+package events
+
+import (
+	"github.com/sha1sum/distinguished_taste_society_bots/groupme"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+)
+
+// addToCalendar creates a GroupMe calendar event for an event posted in a group, named by its number in the last list
+// or its ID, and shares it with an event attachment.
+func (handler Handler) addToCalendar(args []string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	if len(args) < 1 {
+		return &bot.OutgoingMessage{Text: "Which event? Use its number in the last list, e.g. \"!events add 2\"."}
+	}
+	if !handler.Calendar.Enabled() {
+		return &bot.OutgoingMessage{Text: "Adding events to the group calendar isn't set up yet."}
+	}
+	event, err := handler.FindEvent(message.GroupID, args[0])
+	if err != nil {
+		return &bot.OutgoingMessage{Text: err.Error()}
+	}
+	description := event.URL
+	if event.Description != "" {
+		description = event.Description + "\n\n" + event.URL
+	}
+	loc := handler.GroupTimezone(message.GroupID)
+	id, err := handler.Calendar.CreateEvent(message.GroupID, groupme.CalendarEvent{
+		Name:        event.Title,
+		Description: description,
+		Start:       event.StartIn(loc),
+		End:         event.EndIn(loc),
+		AllDay:      event.AllDay,
+		Timezone:    loc.String(),
+		Location:    event.VenueName,
+		Address:     event.City,
+		Latitude:    event.Latitude,
+		Longitude:   event.Longitude,
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{
+		Text: "Added to the group calendar: " + FormatEvent(event, loc),
+		Attachments: []bot.Attachment{
+			bot.Attachment{
+				Type:    "event",
+				EventID: id,
+				View:    "full",
+			},
+		},
+	}
+}
@@ -0,0 +1,212 @@
+package events
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+// DefaultTicketmasterURL is the root of the Ticketmaster Discovery API.
+const DefaultTicketmasterURL = "https://app.ticketmaster.com/discovery/v2"
+
+// TicketmasterProvider searches the Ticketmaster Discovery API, or another API that answers the same way.
+type TicketmasterProvider struct {
+	Key string
+	// URL is the root of the API; blank means DefaultTicketmasterURL
+	URL string
+	// Client makes the requests; nil means a client with a 15 second timeout
+	Client *http.Client
+}
+
+type (
+	// discoveryResponse is a Discovery API event search response.
+	discoveryResponse struct {
+		Embedded struct {
+			Events []discoveryEvent `json:"events"`
+		} `json:"_embedded"`
+		Page struct {
+			Size          int `json:"size"`
+			TotalElements int `json:"totalElements"`
+			TotalPages    int `json:"totalPages"`
+			// Number counts from 0
+			Number int `json:"number"`
+		} `json:"page"`
+	}
+
+	// discoveryEvent is an event in a Discovery API response.
+	discoveryEvent struct {
+		ID    string `json:"id"`
+		Name  string `json:"name"`
+		URL   string `json:"url"`
+		Info  string `json:"info"`
+		Dates struct {
+			Start discoveryDate `json:"start"`
+			End   discoveryDate `json:"end"`
+			// Timezone is the IANA name of the zone the local dates and times are in
+			Timezone string `json:"timezone"`
+		} `json:"dates"`
+		Images []struct {
+			URL   string `json:"url"`
+			Width int    `json:"width"`
+		} `json:"images"`
+		Embedded struct {
+			Venues []struct {
+				Name string `json:"name"`
+				City struct {
+					Name string `json:"name"`
+				} `json:"city"`
+				Location struct {
+					Latitude  string `json:"latitude"`
+					Longitude string `json:"longitude"`
+				} `json:"location"`
+				Timezone string `json:"timezone"`
+			} `json:"venues"`
+		} `json:"_embedded"`
+	}
+
+	// discoveryDate is the start or end of a Discovery API event.
+	discoveryDate struct {
+		LocalDate string `json:"localDate"`
+		LocalTime string `json:"localTime"`
+		DateTime  string `json:"dateTime"`
+		// TimeTBA and NoSpecificTime are set when there's a date but no time
+		TimeTBA        bool `json:"timeTBA"`
+		NoSpecificTime bool `json:"noSpecificTime"`
+	}
+)
+
+// zipCode matches US ZIP codes, which the Discovery API takes separately from city names.
+var zipCode = regexp.MustCompile(`^\d{5}$`)
+
+// Search returns one page of the events matching a request.
+func (p TicketmasterProvider) Search(req SearchRequest, page, perPage int) (Results, error) {
+	params := url.Values{
+		"apikey":  {p.Key},
+		"keyword": {req.Term},
+		"size":    {strconv.Itoa(perPage)},
+		"page":    {strconv.Itoa(page - 1)},
+		"sort":    {"date,asc"},
+		"unit":    {"miles"},
+	}
+	if req.Sort != "" && req.Sort != "date" {
+		params.Set("sort", req.Sort)
+	}
+	if req.Location != "" {
+		if zipCode.MatchString(req.Location) {
+			params.Set("postalCode", req.Location)
+		} else {
+			params.Set("city", req.Location)
+		}
+	}
+	if req.Radius > 0 {
+		params.Set("radius", strconv.Itoa(req.Radius))
+	}
+	if !req.Start.IsZero() {
+		params.Set("startDateTime", req.Start.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	if !req.End.IsZero() {
+		params.Set("endDateTime", req.End.UTC().Format("2006-01-02T15:04:05Z"))
+	}
+	var res discoveryResponse
+	if err := p.get("/events.json", params, &res); err != nil {
+		return Results{}, err
+	}
+	results := Results{Total: res.Page.TotalElements, Page: res.Page.Number + 1, PageCount: res.Page.TotalPages}
+	for _, v := range res.Embedded.Events {
+		results.Events = append(results.Events, v.event(req.zone()))
+	}
+	return results, nil
+}
+
+// SearchAll returns the events matching a request from up to maxPages pages of 100.
+func (p TicketmasterProvider) SearchAll(req SearchRequest, maxPages int) ([]Event, error) {
+	return searchAllPages(p, req, 100, maxPages)
+}
+
+// Get returns the event with the given Discovery API ID.
+func (p TicketmasterProvider) Get(id string) (Event, error) {
+	var v discoveryEvent
+	if err := p.get("/events/"+url.PathEscape(id)+".json", url.Values{"apikey": {p.Key}}, &v); err != nil {
+		return Event{}, err
+	}
+	if v.ID == "" {
+		return Event{}, errNoEvent
+	}
+	return v.event(time.UTC), nil
+}
+
+// get requests a path under the API's root and decodes the JSON response into v.
+func (p TicketmasterProvider) get(path string, params url.Values, v interface{}) error {
+	root := p.URL
+	if root == "" {
+		root = DefaultTicketmasterURL
+	}
+	client := p.Client
+	if client == nil {
+		client = &http.Client{Timeout: 15 * time.Second}
+	}
+	resp, err := client.Get(root + path + "?" + params.Encode())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return errNoEvent
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Ticketmaster responded with HTTP %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+
+// event converts a Discovery API event. Local times are read in the event's zone, or its venue's, or else fallback.
+func (v discoveryEvent) event(fallback *time.Location) Event {
+	zone := v.Dates.Timezone
+	if zone == "" && len(v.Embedded.Venues) > 0 {
+		zone = v.Embedded.Venues[0].Timezone
+	}
+	loc := loadZone(zone, fallback)
+	event := Event{
+		ID:          v.ID,
+		Title:       v.Name,
+		URL:         v.URL,
+		Description: v.Info,
+	}
+	event.Start, event.AllDay = v.Dates.Start.time(loc)
+	event.End, _ = v.Dates.End.time(loc)
+	if len(v.Embedded.Venues) > 0 {
+		venue := v.Embedded.Venues[0]
+		event.VenueName = venue.Name
+		event.City = venue.City.Name
+		event.Latitude, _ = strconv.ParseFloat(venue.Location.Latitude, 64)
+		event.Longitude, _ = strconv.ParseFloat(venue.Location.Longitude, 64)
+	}
+	// The widest image is the best one to show.
+	width := 0
+	for _, image := range v.Images {
+		if image.Width > width {
+			event.ImageURL = image.URL
+			width = image.Width
+		}
+	}
+	return event
+}
+
+// time reads a Discovery API date, preferring the exact UTC time when there is one and otherwise reading the local
+// time in loc. It reports whether the date has no time, in which case it's kept at midnight UTC.
+func (d discoveryDate) time(loc *time.Location) (time.Time, bool) {
+	if !d.TimeTBA && !d.NoSpecificTime {
+		if t, err := time.Parse(time.RFC3339, d.DateTime); err == nil {
+			return t, false
+		}
+		if t, err := time.ParseInLocation("2006-01-02 15:04:05", d.LocalDate+" "+d.LocalTime, loc); err == nil {
+			return t, false
+		}
+	}
+	t, err := time.Parse("2006-01-02", d.LocalDate)
+	return t, err == nil
+}
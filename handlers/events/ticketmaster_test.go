@@ -0,0 +1,105 @@
+package events
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// ticketmasterStandIn answers Discovery API searches with three matching events, paged, and gets with one known event.
+func ticketmasterStandIn(t *testing.T) *httptest.Server {
+	events := []string{
+		`{"id":"T0","name":"Jazz 0","dates":{"start":{"dateTime":"2026-10-20T23:30:00Z"}}}`,
+		`{"id":"T1","name":"Jazz 1","dates":{"start":{"localDate":"2026-10-21","localTime":"19:30:00"},` +
+			`"timezone":"America/Chicago"}}`,
+		`{"id":"T2","name":"Jazz 2","dates":{"start":{"localDate":"2026-10-22","localTime":"19:30:00"}}}`,
+	}
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		if q.Get("apikey") != "key" {
+			t.Errorf("apikey = %q, want key", q.Get("apikey"))
+		}
+		switch r.URL.Path {
+		case "/events.json":
+			if q.Get("postalCode") != "33701" || q.Get("radius") != "25" {
+				t.Errorf("search parameters = %v", q)
+			}
+			var page, size int
+			fmt.Sscan(q.Get("page"), &page)
+			fmt.Sscan(q.Get("size"), &size)
+			found := ""
+			for i := page * size; i < (page+1)*size && i < len(events); i++ {
+				if found != "" {
+					found += ","
+				}
+				found += events[i]
+			}
+			fmt.Fprintf(w, `{"_embedded":{"events":[%s]},"page":{"size":%d,"totalElements":%d,"totalPages":%d,"number":%d}}`,
+				found, size, len(events), (len(events)+size-1)/size, page)
+		case "/events/T3.json":
+			fmt.Fprint(w, `{"id":"T3","name":"Jazz 3","dates":{"start":{"localDate":"2026-10-23","noSpecificTime":true}},`+
+				`"_embedded":{"venues":[{"name":"The Hall","city":{"name":"Tampa"},`+
+				`"location":{"latitude":"27.95","longitude":"-82.46"},"timezone":"America/New_York"}]}}`)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+}
+
+func TestTicketmasterSearch(t *testing.T) {
+	server := ticketmasterStandIn(t)
+	defer server.Close()
+	p := TicketmasterProvider{Key: "key", URL: server.URL}
+	req := SearchRequest{Term: "jazz", Location: "33701", Radius: 25, Timezone: "America/Denver"}
+
+	res, err := p.Search(req, 1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 3 || res.Page != 1 || res.PageCount != 2 || len(res.Events) != 2 {
+		t.Fatalf("page 1 = %+v", res)
+	}
+	if want := time.Date(2026, 10, 20, 23, 30, 0, 0, time.UTC); !res.Events[0].Start.Equal(want) {
+		t.Errorf("exact start = %v, want %v", res.Events[0].Start, want)
+	}
+	chicago, _ := time.LoadLocation("America/Chicago")
+	if want := time.Date(2026, 10, 21, 19, 30, 0, 0, chicago); !res.Events[1].Start.Equal(want) {
+		t.Errorf("local start = %v, want %v in the event's zone", res.Events[1].Start, want)
+	}
+
+	res, err = p.Search(req, 2, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Page != 2 || len(res.Events) != 1 {
+		t.Fatalf("page 2 = %+v", res)
+	}
+	// Without a zone from the event or its venue, the request's is used.
+	denver, _ := time.LoadLocation("America/Denver")
+	if want := time.Date(2026, 10, 22, 19, 30, 0, 0, denver); !res.Events[0].Start.Equal(want) {
+		t.Errorf("fallback start = %v, want %v", res.Events[0].Start, want)
+	}
+}
+
+func TestTicketmasterGet(t *testing.T) {
+	server := ticketmasterStandIn(t)
+	defer server.Close()
+	p := TicketmasterProvider{Key: "key", URL: server.URL}
+
+	v, err := p.Get("T3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v.VenueName != "The Hall" || v.City != "Tampa" || v.Latitude != 27.95 {
+		t.Errorf("venue = %+v", v)
+	}
+	if want := time.Date(2026, 10, 23, 0, 0, 0, 0, time.UTC); !v.AllDay || !v.Start.Equal(want) {
+		t.Errorf("all-day event = %v (all day %v), want %v", v.Start, v.AllDay, want)
+	}
+
+	if _, err := p.Get("missing"); err != errNoEvent {
+		t.Errorf("Get(missing) error = %v, want errNoEvent", err)
+	}
+}
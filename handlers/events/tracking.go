@@ -0,0 +1,191 @@
+package events
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const searchesCollection = "groupmeEventSearchesV1"
+
+// searchArea returns where a search is run: the place and distance it names, or else the group's location and the
+// handler's radius.
+func (handler Handler) searchArea(q query, groupID string) (string, int) {
+	location := q.Location
+	if location == "" {
+		location = handler.groupLocation(groupID)
+	}
+	radius := q.Radius
+	if radius == 0 {
+		radius = handler.Radius
+	}
+	if radius == 0 {
+		radius = 100
+	}
+	return location, radius
+}
+
+// track subscribes the sender to new events matching a search, like "jazz near tampa within 25mi". A delivery mode
+// can follow, as in "jazz weekly"; without one, someone already tracking the search keeps theirs.
+func (handler Handler) track(text string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	delivery := ""
+	if words := strings.Fields(text); len(words) > 1 {
+		if d, ok := parseDelivery(words[len(words)-1]); ok {
+			delivery = d
+			text = strings.Join(words[:len(words)-1], " ")
+		}
+	}
+	q := parseQuery(text)
+	term := strings.ToLower(q.Term)
+	if len(term) < 4 {
+		return &bot.OutgoingMessage{Text: "You must provide a search term at least 4 characters in length, e.g. \"!events track jazz\"."}
+	}
+	location, radius := handler.searchArea(q, message.GroupID)
+	if location == "" {
+		return &bot.OutgoingMessage{Text: "No location is set for event searches. Set one with \"!events location <place>\"."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(searchesCollection)
+	var td eventSearch
+	selector := bson.M{"term": term, "group_id": message.GroupID, "location": location, "radius": radius}
+	if err := col.Find(selector).One(&td); err != nil && err != mgo.ErrNotFound {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	for _, existing := range td.Users {
+		if delivery == "" && existing.UserID == message.UserID {
+			delivery = existing.delivery()
+		}
+	}
+	if delivery == "" {
+		delivery = deliverImmediate
+	}
+	u := user{UserID: message.UserID, Name: message.Name, Delivery: delivery}
+	if len(td.Term) < 1 {
+		err = col.Insert(eventSearch{
+			Term:          term,
+			GroupID:       message.GroupID,
+			Location:      location,
+			Radius:        radius,
+			LatestCreated: time.Unix(0, 0).UTC(),
+			Users:         []user{u},
+		})
+	} else {
+		// The user is taken out and put back so that their name is kept up to date.
+		err = col.UpdateId(td.ID, bson.M{"$pull": bson.M{"users": bson.M{"user_id": u.UserID}}})
+		if err == nil {
+			err = col.UpdateId(td.ID, bson.M{"$push": bson.M{"users": u}})
+		}
+	}
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "New events for \"" + term + "\" near " + location + " will now be tracked, " +
+		"and you'll get them " + describeDelivery(delivery) + ". Stop with \"!events stop " + term + "\"."}
+}
+
+// stopTracking unsubscribes the sender from a search term, wherever it's run, including searches saved before they
+// belonged to a group. Searches nobody is tracking any more are deleted.
+func stopTracking(term string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	term = strings.ToLower(strings.TrimSpace(term))
+	if term == "" {
+		return &bot.OutgoingMessage{Text: "Which search should I stop tracking? See yours with \"!events mine\"."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(searchesCollection)
+	selector := bson.M{"term": term, "group_id": inGroup(message.GroupID), "users.user_id": message.UserID}
+	info, err := col.UpdateAll(selector, bson.M{"$pull": bson.M{"users": bson.M{"user_id": message.UserID}}})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	if info.Updated == 0 {
+		return &bot.OutgoingMessage{Text: "You aren't tracking \"" + term + "\". See yours with \"!events mine\"."}
+	}
+	_, err = col.RemoveAll(bson.M{"term": term, "group_id": inGroup(message.GroupID), "users": bson.M{"$size": 0}})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "You'll no longer hear about new events for \"" + term + "\"."}
+}
+
+// inGroup selects the searches saved in a group along with any saved before searches belonged to one, which have no
+// group ID, so that the people tracking those can still find and stop them.
+func inGroup(groupID string) bson.M {
+	return bson.M{"$in": []interface{}{groupID, "", nil}}
+}
+
+// listMine lists the searches the sender is tracking in the group.
+func listMine(message bot.IncomingMessage) *bot.OutgoingMessage {
+	searches, err := findSearches(bson.M{"group_id": inGroup(message.GroupID), "users.user_id": message.UserID})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	if len(searches) == 0 {
+		return &bot.OutgoingMessage{Text: "You aren't tracking any event searches. Start with \"!events track <term>\"."}
+	}
+	lines := make([]string, len(searches))
+	for i, search := range searches {
+		lines[i] = fmt.Sprintf("%d. %s", i+1, describeSearch(search))
+		for _, u := range search.Users {
+			if u.UserID == message.UserID && u.delivery() != deliverImmediate {
+				lines[i] += " (" + u.delivery() + ")"
+			}
+		}
+	}
+	return &bot.OutgoingMessage{Text: "Event searches you're tracking:\n" + strings.Join(lines, "\n")}
+}
+
+// listTracked lists every search tracked in the group, with how many people are tracking each.
+func listTracked(message bot.IncomingMessage) *bot.OutgoingMessage {
+	searches, err := findSearches(bson.M{"group_id": message.GroupID})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	lines := make([]string, 0, len(searches))
+	for _, search := range searches {
+		if len(search.Users) == 0 {
+			continue
+		}
+		people := "people"
+		if len(search.Users) == 1 {
+			people = "person"
+		}
+		lines = append(lines, fmt.Sprintf("%d. %s (%d %s)", len(lines)+1, describeSearch(search), len(search.Users), people))
+	}
+	if len(lines) == 0 {
+		return &bot.OutgoingMessage{Text: "Nobody here is tracking any event searches. Start with \"!events track <term>\"."}
+	}
+	return &bot.OutgoingMessage{Text: "Event searches tracked here:\n" + strings.Join(lines, "\n")}
+}
+
+// findSearches loads the saved searches matching selector, sorted by term.
+func findSearches(selector bson.M) ([]eventSearch, error) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		return nil, err
+	}
+	defer sess.Close()
+	var searches []eventSearch
+	err = sess.DB(DB).C(searchesCollection).Find(selector).Sort("term", "location").All(&searches)
+	return searches, err
+}
+
+// describeSearch renders a saved search as its term and where it's run.
+func describeSearch(search eventSearch) string {
+	if search.Location == "" {
+		return "\"" + search.Term + "\""
+	}
+	return "\"" + search.Term + "\" near " + search.Location
+}
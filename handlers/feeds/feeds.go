@@ -0,0 +1,438 @@
+/*
+Package feeds lets a GroupMe group subscribe to any RSS, Atom or JSON feed, like a blog or podcast. Subscriptions are
+managed with "!feed add <url> [name]", "!feed remove <name>", "!feed list", "!feed latest <name>" and
+"!feed interval <name> <minutes>". Each group's subscriptions are kept in MongoDB (located with the MONGOLAB_URI and
+MONGOLAB_DB environment variables), and a poller announces new items from each feed as they show up.
+
+Items are recognized by their GUID (or link, for feeds without GUIDs), so an edited item isn't announced twice. A feed
+that keeps failing is polled less and less often, up to once a day, until it works again.
+*/
+package feeds
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Handler will satisfy the bot.Handler interface.
+type Handler struct {
+	// Interval is the poll interval given to new subscriptions
+	Interval time.Duration
+	// MaxAnnounce is the most new items announced from one feed in a single poll
+	MaxAnnounce int
+}
+
+// subscription is a group's stored subscription to a single feed.
+type subscription struct {
+	ID      bson.ObjectId `bson:"_id"`
+	GroupID string        `bson:"group_id"`
+	Name    string        `bson:"name"`
+	URL     string        `bson:"url"`
+	Title   string        `bson:"title"`
+	// Category is the folder the feed is filed under in OPML exports
+	Category string    `bson:"category"`
+	AddedBy  string    `bson:"added_by"`
+	Added    time.Time `bson:"added"`
+	// Interval is the time between polls, in minutes
+	Interval  int       `bson:"interval"`
+	NextPoll  time.Time `bson:"next_poll"`
+	Failures  int       `bson:"failures"`
+	LastError string    `bson:"last_error"`
+	// Seen holds the IDs of the most recent items, so that only new ones are announced
+	Seen []string `bson:"seen"`
+	// Pending is set on feeds that were imported without being fetched. Their first poll just fills in Seen.
+	Pending bool `bson:"pending"`
+}
+
+const (
+	collection = "groupmeFeedsV1"
+	// maxSeen is the number of item IDs remembered per feed. It needs to be more than any feed shows at once.
+	maxSeen = 500
+	// maxBackoff is the longest a failing feed goes between polls.
+	maxBackoff = 24 * time.Hour
+	// maxSnippet is the longest bit of an item's description posted with it.
+	maxSnippet = 200
+	usage      = "Use \"!feed add <url> [name]\", \"!feed remove <name>\", \"!feed list\", \"!feed latest <name>\" or " +
+		"\"!feed interval <name> <minutes>\"."
+)
+
+// nameCleaner strips everything but letters, numbers and dashes out of a generated feed name.
+var nameCleaner = regexp.MustCompile(`[^a-z0-9-]+`)
+
+// DB is the name of the MongoDB database
+var DB = handlers.DB
+
+// Handle manages the feed subscriptions for the group the message was posted in.
+func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" {
+		return
+	}
+	args := handlers.Arguments(message.Text, "feed")
+	if len(args) < 1 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: usage}}
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	var text string
+	switch strings.ToLower(args[0]) {
+	case "add":
+		text, err = handler.add(col, args[1:], message)
+	case "remove":
+		text, err = remove(col, message.GroupID, args[1:])
+	case "list":
+		text, err = list(col, message.GroupID)
+	case "latest":
+		text, err = latest(col, message.GroupID, args[1:])
+	case "interval":
+		text, err = setInterval(col, message.GroupID, args[1:])
+	default:
+		text = usage
+	}
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: text}}
+}
+
+// interval returns the poll interval for new subscriptions, in minutes.
+func (handler Handler) interval() int {
+	if handler.Interval < time.Minute {
+		return 30
+	}
+	return int(handler.Interval / time.Minute)
+}
+
+// maxAnnounce returns the most items to announce from a feed at once.
+func (handler Handler) maxAnnounce() int {
+	if handler.MaxAnnounce < 1 {
+		return 3
+	}
+	return handler.MaxAnnounce
+}
+
+// find loads one of a group's subscriptions by name.
+func find(col *mgo.Collection, groupID, name string) (subscription, error) {
+	var sub subscription
+	err := col.Find(bson.M{"group_id": groupID, "name": strings.ToLower(name)}).One(&sub)
+	if err == mgo.ErrNotFound {
+		return sub, errors.New("There's no feed called \"" + name + "\". Try \"!feed list\".")
+	}
+	return sub, err
+}
+
+// add subscribes the group to a feed. The feed is fetched right away, both to check that it works and so that the
+// items already in it aren't announced as new.
+func (handler Handler) add(col *mgo.Collection, args []string, message bot.IncomingMessage) (string, error) {
+	if len(args) < 1 {
+		return "", errors.New("You need to give the feed's address, like \"!feed add https://example.com/feed\".")
+	}
+	link := strings.Trim(args[0], "<>")
+	parsed, err := url.Parse(link)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return "", errors.New("\"" + args[0] + "\" isn't a web address.")
+	}
+	feed, err := matchers.RetrieveFeed(link)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't read a feed from %s: %v", link, err)
+	}
+	name := strings.ToLower(strings.Join(args[1:], "-"))
+	if name == "" {
+		name = feedName(feed.Title, parsed)
+	}
+	name = nameCleaner.ReplaceAllString(name, "")
+	if name == "" {
+		return "", errors.New("Feed names can only use letters, numbers and dashes.")
+	}
+	n, err := col.Find(bson.M{"group_id": message.GroupID, "$or": []bson.M{{"name": name}, {"url": link}}}).Count()
+	if err != nil {
+		return "", err
+	}
+	if n > 0 {
+		return "", errors.New("This group already follows that feed, or has one called \"" + name + "\".")
+	}
+	now := time.Now()
+	sub := subscription{
+		ID:       bson.NewObjectId(),
+		GroupID:  message.GroupID,
+		Name:     name,
+		URL:      link,
+		Title:    feed.Title,
+		AddedBy:  message.UserID,
+		Added:    now,
+		Interval: handler.interval(),
+		NextPoll: now.Add(time.Duration(handler.interval()) * time.Minute),
+		Seen:     remember(nil, feed.Items),
+	}
+	err = col.Insert(sub)
+	if err != nil {
+		return "", err
+	}
+	title := feed.Title
+	if title == "" {
+		title = link
+	}
+	return "Now following " + title + " as \"" + name + "\". New items will be posted here.", nil
+}
+
+// feedName makes up a short name for a feed from its title, or its host if it has no title.
+func feedName(title string, link *url.URL) string {
+	words := strings.Fields(strings.ToLower(title))
+	if len(words) > 3 {
+		words = words[:3]
+	}
+	name := nameCleaner.ReplaceAllString(strings.Join(words, "-"), "")
+	if name == "" {
+		name = nameCleaner.ReplaceAllString(strings.Replace(strings.TrimPrefix(link.Host, "www."), ".", "-", -1), "")
+	}
+	return name
+}
+
+// remove unsubscribes the group from a feed.
+func remove(col *mgo.Collection, groupID string, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", errors.New("You need to say which feed to remove.")
+	}
+	sub, err := find(col, groupID, args[0])
+	if err != nil {
+		return "", err
+	}
+	err = col.RemoveId(sub.ID)
+	if err != nil {
+		return "", err
+	}
+	return "No longer following \"" + sub.Name + "\".", nil
+}
+
+// list describes the group's subscriptions.
+func list(col *mgo.Collection, groupID string) (string, error) {
+	var subs []subscription
+	err := col.Find(bson.M{"group_id": groupID}).Sort("name").All(&subs)
+	if err != nil {
+		return "", err
+	}
+	if len(subs) < 1 {
+		return "This group doesn't follow any feeds yet. Add one with \"!feed add <url> [name]\".", nil
+	}
+	text := "Feeds followed here:"
+	for _, sub := range subs {
+		text += "\n" + sub.Name + ": " + sub.URL + " (every " + strconv.Itoa(sub.Interval) + " min"
+		if sub.Category != "" {
+			text += ", in " + sub.Category
+		}
+		text += ")"
+		if sub.Failures > 0 {
+			text += " [failing: " + sub.LastError + "]"
+		}
+	}
+	return text, nil
+}
+
+// latest posts the most recent item from one of the group's feeds.
+func latest(col *mgo.Collection, groupID string, args []string) (string, error) {
+	if len(args) < 1 {
+		return "", errors.New("You need to say which feed.")
+	}
+	sub, err := find(col, groupID, args[0])
+	if err != nil {
+		return "", err
+	}
+	feed, err := matchers.RetrieveFeed(sub.URL)
+	if err != nil {
+		return "", fmt.Errorf("Couldn't read \"%s\": %v", sub.Name, err)
+	}
+	if len(feed.Items) < 1 {
+		return "\"" + sub.Name + "\" doesn't have any items right now.", nil
+	}
+	newest := feed.Items[0]
+	for _, item := range feed.Items[1:] {
+		if item.Published.After(newest.Published) {
+			newest = item
+		}
+	}
+	return formatItem(sub, newest), nil
+}
+
+// setInterval changes how often one of the group's feeds is polled.
+func setInterval(col *mgo.Collection, groupID string, args []string) (string, error) {
+	if len(args) < 2 {
+		return "", errors.New("You need to give a feed and a number of minutes, like \"!feed interval xkcd 60\".")
+	}
+	minutes, err := strconv.Atoi(strings.TrimSuffix(strings.ToLower(args[1]), "m"))
+	if err != nil || minutes < 5 {
+		return "", errors.New("The interval needs to be a number of minutes, at least 5.")
+	}
+	sub, err := find(col, groupID, args[0])
+	if err != nil {
+		return "", err
+	}
+	err = col.UpdateId(sub.ID, bson.M{"$set": bson.M{
+		"interval":  minutes,
+		"next_poll": time.Now().Add(time.Duration(minutes) * time.Minute),
+	}})
+	if err != nil {
+		return "", err
+	}
+	return "\"" + sub.Name + "\" will be checked every " + strconv.Itoa(minutes) + " minutes.", nil
+}
+
+// formatItem renders a feed item for posting, with a plain-text snippet of its description when it has one.
+func formatItem(sub subscription, item matchers.FeedItem) string {
+	title := item.Title
+	if title == "" {
+		title = "(untitled)"
+	}
+	text := sub.Name + ": " + title + " " + item.Link
+	description := item.Description
+	if description == "" {
+		description = item.Content
+	}
+	if s := matchers.Snippet(description, maxSnippet); s != "" && s != title {
+		text += "\n" + s
+	}
+	return text
+}
+
+// remember adds the IDs of items to the seen list, keeping only the most recent maxSeen.
+func remember(seen []string, items []matchers.FeedItem) []string {
+	for _, item := range items {
+		if id := itemID(item); id != "" {
+			seen = append(seen, id)
+		}
+	}
+	if len(seen) > maxSeen {
+		seen = seen[len(seen)-maxSeen:]
+	}
+	return seen
+}
+
+// itemID returns the key used to recognize an item between polls.
+func itemID(item matchers.FeedItem) string {
+	if item.ID != "" {
+		return item.ID
+	}
+	return item.Link
+}
+
+// SetupPolling starts checking every minute for groupID's feeds that are due to be polled, and posts their new items
+// using botID. A bot can only post in the group it was added to, so botID has to be that group's.
+func (handler Handler) SetupPolling(botID, groupID string) {
+	ticker := time.NewTicker(time.Minute)
+	go func(handler Handler, botID string) {
+		for range ticker.C {
+			handler.pollDue(botID, groupID, time.Now())
+		}
+	}(handler, botID)
+}
+
+// pollDue polls every one of groupID's feeds whose next poll time has come.
+func (handler Handler) pollDue(botID, groupID string, now time.Time) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	var subs []subscription
+	err = col.Find(bson.M{"group_id": groupID, "next_poll": bson.M{"$lte": now}}).All(&subs)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, sub := range subs {
+		for _, m := range handler.poll(col, sub, now) {
+			_, err = bot.PostMessage(m, botID)
+			if err != nil {
+				fmt.Println(err)
+			}
+			time.Sleep(time.Second)
+		}
+	}
+}
+
+// poll fetches a feed, records the result, and returns messages for any items that haven't been seen before. Errors
+// push the next poll back exponentially.
+func (handler Handler) poll(col *mgo.Collection, sub subscription, now time.Time) []*bot.OutgoingMessage {
+	feed, err := matchers.RetrieveFeed(sub.URL)
+	if err != nil {
+		fmt.Printf("Feed %s failed: %v\n", sub.URL, err)
+		err = col.UpdateId(sub.ID, bson.M{"$set": bson.M{
+			"failures":   sub.Failures + 1,
+			"last_error": err.Error(),
+			"next_poll":  now.Add(backoff(sub.Interval, sub.Failures+1)),
+		}})
+		if err != nil {
+			fmt.Println(err)
+		}
+		return nil
+	}
+	seen := make(map[string]bool)
+	for _, id := range sub.Seen {
+		seen[id] = true
+	}
+	fresh := make([]matchers.FeedItem, 0)
+	for _, item := range feed.Items {
+		id := itemID(item)
+		if id == "" || seen[id] {
+			continue
+		}
+		seen[id] = true
+		fresh = append(fresh, item)
+	}
+	err = col.UpdateId(sub.ID, bson.M{"$set": bson.M{
+		"failures":   0,
+		"last_error": "",
+		"title":      feed.Title,
+		"next_poll":  now.Add(time.Duration(sub.Interval) * time.Minute),
+		"seen":       remember(sub.Seen, fresh),
+		"pending":    false,
+	}})
+	if err != nil {
+		// Without the seen list saved, these items would be announced again next time.
+		fmt.Println(err)
+		return nil
+	}
+	if sub.Pending {
+		return nil
+	}
+	// Feeds list their newest items first, so announce the newest few, oldest of those first.
+	if len(fresh) > handler.maxAnnounce() {
+		fresh = fresh[:handler.maxAnnounce()]
+	}
+	messages := make([]*bot.OutgoingMessage, 0, len(fresh))
+	for i := len(fresh) - 1; i >= 0; i-- {
+		messages = append(messages, &bot.OutgoingMessage{Text: formatItem(sub, fresh[i])})
+	}
+	return messages
+}
+
+// backoff returns how long to wait before polling a feed that has failed the given number of times in a row,
+// doubling the feed's interval for each failure.
+func backoff(interval, failures int) time.Duration {
+	wait := time.Duration(interval) * time.Minute
+	for i := 0; i < failures && wait < maxBackoff; i++ {
+		wait *= 2
+	}
+	if wait > maxBackoff {
+		wait = maxBackoff
+	}
+	return wait
+}
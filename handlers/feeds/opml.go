@@ -0,0 +1,159 @@
+package feeds
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxOPMLSize is the largest OPML document that will be imported.
+const maxOPMLSize = 2 * 1024 * 1024
+
+// ImportOPML subscribes a group to every feed in an OPML document, keeping the feeds' names and categories. Feeds the
+// group already follows are skipped. The feeds aren't fetched during the import; instead their first poll records
+// what's already in them without announcing it.
+func (handler Handler) ImportOPML(groupID string, r io.Reader) (added, skipped int, err error) {
+	data, err := ioutil.ReadAll(io.LimitReader(r, maxOPMLSize))
+	if err != nil {
+		return 0, 0, err
+	}
+	subs, err := matchers.ParseOPML(data)
+	if err != nil {
+		return 0, 0, err
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	now := time.Now()
+	for _, s := range subs {
+		parsed, err := url.Parse(s.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") {
+			skipped++
+			continue
+		}
+		n, err := col.Find(bson.M{"group_id": groupID, "url": s.URL}).Count()
+		if err != nil {
+			return added, skipped, err
+		}
+		if n > 0 {
+			skipped++
+			continue
+		}
+		name, err := uniqueName(col, groupID, feedName(s.Title, parsed))
+		if err != nil {
+			return added, skipped, err
+		}
+		err = col.Insert(subscription{
+			ID:       bson.NewObjectId(),
+			GroupID:  groupID,
+			Name:     name,
+			URL:      s.URL,
+			Title:    s.Title,
+			Category: s.Category,
+			AddedBy:  "opml",
+			Added:    now,
+			Interval: handler.interval(),
+			NextPoll: now,
+			Pending:  true,
+		})
+		if err != nil {
+			return added, skipped, err
+		}
+		added++
+	}
+	return added, skipped, nil
+}
+
+// uniqueName returns name, or name with a number on the end if the group already has a feed called name.
+func uniqueName(col *mgo.Collection, groupID, name string) (string, error) {
+	candidate := name
+	for i := 2; ; i++ {
+		n, err := col.Find(bson.M{"group_id": groupID, "name": candidate}).Count()
+		if err != nil {
+			return "", err
+		}
+		if n < 1 {
+			return candidate, nil
+		}
+		candidate = name + "-" + strconv.Itoa(i)
+	}
+}
+
+// ExportOPML writes a group's subscriptions out as an OPML document.
+func ExportOPML(groupID string, w io.Writer) error {
+	sess, err := handlers.Dial()
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+	var subs []subscription
+	err = sess.DB(DB).C(collection).Find(bson.M{"group_id": groupID}).Sort("name").All(&subs)
+	if err != nil {
+		return err
+	}
+	list := make([]matchers.Subscription, 0, len(subs))
+	for _, s := range subs {
+		title := s.Title
+		if title == "" {
+			title = s.Name
+		}
+		list = append(list, matchers.Subscription{Title: title, URL: s.URL, Category: s.Category})
+	}
+	out, err := matchers.RenderOPML("Feeds for GroupMe group "+groupID, list)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(out)
+	return err
+}
+
+// AdminHandler serves OPML import and export for a group's subscriptions at "?group_id=<id>": GET exports the
+// subscriptions, and POST imports the OPML document in the request body. Every request must carry token in an
+// "Authorization: Bearer <token>" header, and nothing is served when token is blank.
+func (handler Handler) AdminHandler(token string) http.Handler {
+	return http.HandlerFunc(func(writer http.ResponseWriter, request *http.Request) {
+		given := strings.TrimPrefix(request.Header.Get("Authorization"), "Bearer ")
+		if token == "" || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			http.Error(writer, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		groupID := request.URL.Query().Get("group_id")
+		if groupID == "" {
+			http.Error(writer, "group_id is required", http.StatusBadRequest)
+			return
+		}
+		switch request.Method {
+		case "GET":
+			writer.Header().Set("Content-Type", "text/x-opml; charset=utf-8")
+			writer.Header().Set("Content-Disposition", "attachment; filename=\"feeds-"+groupID+".opml\"")
+			err := ExportOPML(groupID, writer)
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusInternalServerError)
+			}
+		case "POST":
+			added, skipped, err := handler.ImportOPML(groupID, request.Body)
+			if err != nil {
+				http.Error(writer, err.Error(), http.StatusBadRequest)
+				return
+			}
+			fmt.Fprintf(writer, "imported %d feeds, skipped %d\n", added, skipped)
+		default:
+			writer.Header().Set("Allow", "GET, POST")
+			http.Error(writer, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+}
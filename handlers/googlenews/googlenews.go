@@ -1,5 +1,9 @@
 /*
 Package googlenews handles downloading and parsing search results for Google News queries output as RSS.
+
+Searches can be narrowed with modifiers mixed in with the search words: "since:24h" or "since:2026-10-01" for a time
+window, "before:2026-10-01" for stories up to the end of that day, "site:nytimes.com" to only take stories from a
+site, and "-site:foxnews.com" to skip one.
 */
 package googlenews
 
@@ -7,13 +11,25 @@ import (
 	"errors"
 	"fmt"
 	"net/url"
+	"strings"
+	"time"
 
+	"github.com/sha1sum/distinguished_taste_society_bots/groupme"
 	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
 	"github.com/sha1sum/golang_groupme_bot/bot"
 )
 
 // Handler will satisfy the bot.Handler interface.
-type Handler struct{}
+type Handler struct {
+	// Previews turns on fetching each story's page so that the reply includes a one-line summary of it
+	Previews bool
+	// Images re-hosts a story's preview image on GroupMe so it can be attached to the reply. Images are skipped when
+	// the service has no access token.
+	Images groupme.ImageService
+}
+
+// maxSummary is the longest summary line posted with a story.
+const maxSummary = 200
 
 // Handle takes a search term and queries Google News for results, then parses the first story's raw link from the
 // RSS output returned by Google News.
@@ -21,8 +37,13 @@ func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, messag
 	if message.SenderType == "bot" {
 		return
 	}
+	q, err := parseQuery(term, time.Now())
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
 	// Fetch the Google news search results for the search term as an RSS feed.
-	doc, err := matchers.Retrieve("http://news.google.com/news?q=" + url.QueryEscape(term) + "&output=rss")
+	doc, err := matchers.Retrieve(FeedURL(q.String()))
 	if err != nil {
 		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
 		return
@@ -35,26 +56,102 @@ func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, messag
 		return
 	}
 	fmt.Println("Link retrieved.")
-	// Get the link with all the Googley stuff in it
-	c <- parseLink(doc.Channel.Item[0])
+	if !q.filtered() {
+		// Get the link with all the Googley stuff in it
+		link, err := OriginalLink(items[0])
+		if err != nil {
+			c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+			return
+		}
+		c <- handler.reply(items[0], link)
+		return
+	}
+	// Google doesn't honor every modifier exactly, so check each story ourselves and post the first that fits.
+	for _, item := range items {
+		link, err := OriginalLink(item)
+		if err != nil {
+			continue
+		}
+		if q.matches(item, link) {
+			c <- handler.reply(item, link)
+			return
+		}
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: errors.New("No results for \"" + term + "\" matched your filters.")}}
+}
+
+// reply builds the message for a story. With previews on, the story's page is fetched so that its title, site and
+// description can be summed up in a line above the link, and its image attached. If the page can't be fetched, a
+// snippet of the feed's own description of the story is used instead.
+func (handler Handler) reply(item matchers.Item, link string) []*bot.OutgoingMessage {
+	m := &bot.OutgoingMessage{Text: link}
+	if !handler.Previews {
+		return []*bot.OutgoingMessage{m}
+	}
+	page, err := matchers.RetrievePage(link)
+	if err != nil {
+		fmt.Println("Couldn't fetch preview:", err)
+		if s := snippet(item.Description); s != "" {
+			m.Text = s + "\n" + link
+		}
+		return []*bot.OutgoingMessage{m}
+	}
+	if s := summarize(page); s != "" {
+		m.Text = s + "\n" + link
+	}
+	if page.Image != "" && handler.Images.Enabled() {
+		img, err := handler.Images.Upload(page.Image)
+		if err != nil {
+			fmt.Println("Couldn't upload preview image:", err)
+		} else {
+			m.Attachments = append(m.Attachments, bot.Attachment{Type: "image", URL: img})
+		}
+	}
+	return []*bot.OutgoingMessage{m}
+}
+
+// summarize renders a page's metadata as a single line, e.g. "Title (Site): Description".
+func summarize(page *matchers.Page) string {
+	s := page.Title
+	if page.SiteName != "" && !strings.Contains(s, page.SiteName) {
+		s += " (" + page.SiteName + ")"
+	}
+	if page.Description != "" {
+		if s != "" {
+			s += ": "
+		}
+		s += page.Description
+	}
+	return matchers.Truncate(s, maxSummary)
+}
+
+// snippet renders a feed description as a single line of plain text.
+func snippet(description string) string {
+	return matchers.Truncate(strings.Replace(matchers.PlainText(description), "\n", " ", -1), maxSummary)
+}
+
+// FeedURL returns the address of the Google News RSS feed of search results for term.
+func FeedURL(term string) string {
+	return "http://news.google.com/news?q=" + url.QueryEscape(term) + "&output=rss"
 }
 
-// parseLink takes an RSS <item> struct and parses the link to the original story from the Google link to the item.
-func parseLink(item matchers.Item) []*bot.OutgoingMessage {
+// OriginalLink pulls the link to the original story out of the Google link to the item, falling back to the item's
+// own link when there isn't one embedded.
+func OriginalLink(item matchers.Item) (string, error) {
 	l := item.Link
 	parsed, err := url.Parse(l)
 	if err != nil {
-		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return "", err
 	}
 	// Get the query string values so we can just get the normal URL instead of the Googley one.
 	queryVals, err := url.ParseQuery(parsed.RawQuery)
 	if err != nil {
-		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return "", err
 	}
 	ls := queryVals["url"]
 	if len(ls) < 1 {
-		return []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: item.Link}}
+		return item.Link, nil
 	}
 	fmt.Println("Found link", ls[0])
-	return []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: ls[0]}}
+	return ls[0], nil
 }
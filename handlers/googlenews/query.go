@@ -0,0 +1,159 @@
+package googlenews
+
+import (
+	"errors"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+)
+
+// dateLayout is how absolute dates are written in "since:" and "before:".
+const dateLayout = "2006-01-02"
+
+// query is a parsed !news search term. Modifiers such as "since:24h", "before:2026-10-01", "site:nytimes.com" and
+// "-site:foxnews.com" are pulled out of the term and kept separately from the plain search words.
+type query struct {
+	Words []string
+	// Since is the earliest an item can have been published, and Before the moment it must have been published
+	// before. A date given for "before:" includes that day, so Before is the start of the next.
+	Since        time.Time
+	Before       time.Time
+	Sites        []string
+	ExcludeSites []string
+}
+
+// parseQuery splits a search term into its plain words and modifiers. Relative times are resolved against now.
+func parseQuery(term string, now time.Time) (query, error) {
+	var q query
+	for _, word := range strings.Fields(term) {
+		lower := strings.ToLower(word)
+		switch {
+		case strings.HasPrefix(lower, "since:"):
+			t, err := parseWhen(lower[len("since:"):], now)
+			if err != nil {
+				return q, err
+			}
+			q.Since = t
+		case strings.HasPrefix(lower, "before:"):
+			value := lower[len("before:"):]
+			t, err := parseWhen(value, now)
+			if err != nil {
+				return q, err
+			}
+			if _, err := time.Parse(dateLayout, value); err == nil {
+				t = t.AddDate(0, 0, 1)
+			}
+			q.Before = t
+		case strings.HasPrefix(lower, "site:"):
+			q.Sites = append(q.Sites, strings.TrimPrefix(lower[len("site:"):], "www."))
+		case strings.HasPrefix(lower, "-site:"):
+			q.ExcludeSites = append(q.ExcludeSites, strings.TrimPrefix(lower[len("-site:"):], "www."))
+		default:
+			q.Words = append(q.Words, word)
+		}
+	}
+	if len(q.Words) < 1 && len(q.Sites) < 1 {
+		return q, errors.New("You must provide something to search for.")
+	}
+	return q, nil
+}
+
+// parseWhen reads either a relative age like "24h", "3d" or "2w" (meaning that long before now) or an absolute date
+// in the form "2006-01-02".
+func parseWhen(value string, now time.Time) (time.Time, error) {
+	if t, err := time.ParseInLocation(dateLayout, value, now.Location()); err == nil {
+		return t, nil
+	}
+	if len(value) < 2 {
+		return time.Time{}, errors.New("Couldn't understand the time \"" + value + "\".")
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return time.Time{}, errors.New("Couldn't understand the time \"" + value + "\".")
+	}
+	switch value[len(value)-1] {
+	case 'h':
+		return now.Add(-time.Duration(n) * time.Hour), nil
+	case 'd':
+		return now.AddDate(0, 0, -n), nil
+	case 'w':
+		return now.AddDate(0, 0, -7*n), nil
+	}
+	return time.Time{}, errors.New("Couldn't understand the time \"" + value + "\".")
+}
+
+// String renders the query in Google News search syntax so that the provider can do as much of the filtering as it
+// supports. Google only understands whole days for after:/before:, and leaves out the day given to before:, so the
+// window is widened to whole days and the exact one is still applied by matches.
+func (q query) String() string {
+	parts := append([]string{}, q.Words...)
+	for _, s := range q.Sites {
+		parts = append(parts, "site:"+s)
+	}
+	for _, s := range q.ExcludeSites {
+		parts = append(parts, "-site:"+s)
+	}
+	if !q.Since.IsZero() {
+		parts = append(parts, "after:"+q.Since.Format(dateLayout))
+	}
+	if !q.Before.IsZero() {
+		day := time.Date(q.Before.Year(), q.Before.Month(), q.Before.Day(), 0, 0, 0, 0, q.Before.Location())
+		if day.Before(q.Before) {
+			day = day.AddDate(0, 0, 1)
+		}
+		parts = append(parts, "before:"+day.Format(dateLayout))
+	}
+	return strings.Join(parts, " ")
+}
+
+// filtered reports whether the query has any modifiers that need to be checked against each item.
+func (q query) filtered() bool {
+	return !q.Since.IsZero() || !q.Before.IsZero() || len(q.Sites) > 0 || len(q.ExcludeSites) > 0
+}
+
+// matches applies the query's time window and site filters to an item, using its parsed pubDate and the host of the
+// story's original link. Items whose date can't be parsed are left out when a time window was asked for.
+func (q query) matches(item matchers.Item, link string) bool {
+	if !q.Since.IsZero() || !q.Before.IsZero() {
+		published, err := item.Published()
+		if err != nil {
+			return false
+		}
+		if !q.Since.IsZero() && published.Before(q.Since) {
+			return false
+		}
+		if !q.Before.IsZero() && !published.Before(q.Before) {
+			return false
+		}
+	}
+	if len(q.Sites) < 1 && len(q.ExcludeSites) < 1 {
+		return true
+	}
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	host := strings.ToLower(parsed.Host)
+	for _, s := range q.ExcludeSites {
+		if hostMatches(host, s) {
+			return false
+		}
+	}
+	if len(q.Sites) < 1 {
+		return true
+	}
+	for _, s := range q.Sites {
+		if hostMatches(host, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// hostMatches reports whether host is site or one of its subdomains.
+func hostMatches(host, site string) bool {
+	return host == site || strings.HasSuffix(host, "."+site)
+}
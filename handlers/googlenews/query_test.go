@@ -0,0 +1,50 @@
+package googlenews
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+)
+
+func TestBeforeDate(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	q, err := parseQuery("jazz before:2026-10-01", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := q.String(); got != "jazz before:2026-10-02" {
+		t.Errorf("String() = %q, want %q", got, "jazz before:2026-10-02")
+	}
+	// The day named is included, and everything after it left out, just as Google does with the day after.
+	for _, test := range []struct {
+		pubDate string
+		want    bool
+	}{
+		{"Wed, 30 Sep 2026 23:59:00 +0000", true},
+		{"Thu, 01 Oct 2026 23:59:00 +0000", true},
+		{"Fri, 02 Oct 2026 00:00:00 +0000", false},
+	} {
+		if got := q.matches(matchers.Item{PubDate: test.pubDate}, "https://example.com/"); got != test.want {
+			t.Errorf("matches(%s) = %v, want %v", test.pubDate, got, test.want)
+		}
+	}
+}
+
+func TestBeforeAge(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	q, err := parseQuery("jazz before:3d", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Google only takes whole days, so it's asked for the whole of the last day and matches trims the rest.
+	if got := q.String(); got != "jazz before:2026-10-17" {
+		t.Errorf("String() = %q, want %q", got, "jazz before:2026-10-17")
+	}
+	if !q.matches(matchers.Item{PubDate: "Fri, 16 Oct 2026 11:59:00 +0000"}, "https://example.com/") {
+		t.Error("a story from before the cutoff was left out")
+	}
+	if q.matches(matchers.Item{PubDate: "Fri, 16 Oct 2026 12:00:00 +0000"}, "https://example.com/") {
+		t.Error("a story from the cutoff was kept")
+	}
+}
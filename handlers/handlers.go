@@ -0,0 +1,54 @@
+/*
+Package handlers holds what the bot's handlers share: reaching the MongoDB instance they keep their data in, and
+reading the words that follow a command's trigger. The handlers themselves are in the packages below this one.
+*/
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"gopkg.in/mgo.v2"
+)
+
+// DB is the name of the MongoDB database the handlers keep their data in, from the MONGOLAB_DB environment variable.
+// It's read once, when the program starts.
+var DB = os.Getenv("MONGOLAB_DB")
+
+// Dial connects to the MongoDB instance named by the MONGOLAB_URI environment variable. The data is kept in DB.
+func Dial() (*mgo.Session, error) {
+	uri := os.Getenv("MONGOLAB_URI")
+	if uri == "" {
+		return nil, errors.New("no connection string provided")
+	}
+	if DB == "" {
+		return nil, errors.New("no database provided")
+	}
+	sess, err := mgo.Dial(uri)
+	if err != nil {
+		return nil, fmt.Errorf("can't connect to mongo: %v", err)
+	}
+	return sess, nil
+}
+
+// Arguments returns the words following a command's trigger in a message's original text, keeping their case so that
+// things like event IDs and links survive. The trigger is "!" and one of the given names, matched as a whole word
+// without regard to case, or with a space after the "!" as mobile keyboards like to add. It returns nil when no
+// trigger is found.
+func Arguments(text string, names ...string) []string {
+	words := strings.Fields(text)
+	for i, w := range words {
+		w = strings.ToLower(w)
+		for _, name := range names {
+			if w == "!"+name {
+				return words[i+1:]
+			}
+			if w == "!" && i+1 < len(words) && strings.ToLower(words[i+1]) == name {
+				return words[i+2:]
+			}
+		}
+	}
+	return nil
+}
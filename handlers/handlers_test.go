@@ -0,0 +1,26 @@
+package handlers
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestArguments(t *testing.T) {
+	for _, test := range []struct {
+		text  string
+		names []string
+		want  []string
+	}{
+		{"!digest add Jazz", []string{"digest"}, []string{"add", "Jazz"}},
+		{"! Digest add Jazz", []string{"digest"}, []string{"add", "Jazz"}},
+		{"hey !DIGEST", []string{"digest"}, []string{}},
+		{"!biodigest add jazz", []string{"digest"}, nil},
+		{"ongoing !going 2", []string{"going", "notgoing"}, []string{"2"}},
+		{"!notgoing E0-001", []string{"going", "notgoing"}, []string{"E0-001"}},
+		{"nothing to see", []string{"feed"}, nil},
+	} {
+		if got := Arguments(test.text, test.names...); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("Arguments(%q, %q) = %q, want %q", test.text, test.names, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,287 @@
+/*
+Package reminders mentions people a while before an event they've asked to be reminded of. Events are picked by their
+number in the last list "!events" posted in the group, or by their ID, with an optional time before the event:
+"!remind 2" or "!remind <event-id> 1d". "!remind list" shows the sender's reminders and "!remind cancel <number>"
+drops one.
+
+Reminders are kept in MongoDB, located with the MONGOLAB_URI and MONGOLAB_DB environment variables, so they survive
+restarts.
+*/
+package reminders
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/events"
+	"github.com/sha1sum/distinguished_taste_society_bots/mentions"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Handler will satisfy the bot.Handler interface.
+type Handler struct {
+	// Events looks up the events reminders are set for
+	Events events.Handler
+	// Before is how long before an event reminders go out when no time is given; it defaults to an hour
+	Before time.Duration
+}
+
+// reminder is one person's reminder of one event.
+type reminder struct {
+	ID      bson.ObjectId `bson:"_id,omitempty"`
+	GroupID string        `bson:"group_id"`
+	UserID  string        `bson:"user_id"`
+	Name    string        `bson:"name"`
+	Event   events.Event  `bson:"event"`
+	// Before is how long before the event the reminder goes out
+	Before time.Duration `bson:"before"`
+	// At is when the reminder goes out
+	At time.Time `bson:"at"`
+}
+
+const (
+	collection = "groupmeRemindersV1"
+	usage      = "Use \"!remind <number>\" for an event in the last \"!events\" list, \"!remind <event-id> 1d\", " +
+		"\"!remind list\" or \"!remind cancel <number>\"."
+)
+
+// offsetPart matches one piece of a time before an event, like the "1d" and "12h" of "1d12h".
+var offsetPart = regexp.MustCompile(`^(\d+)(w|d|h|m)`)
+
+// DB is the name of the MongoDB database
+var DB = handlers.DB
+
+// Handle sets, lists and cancels reminders for the sender.
+func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" {
+		return
+	}
+	args := handlers.Arguments(message.Text, "remind")
+	if len(args) < 1 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: usage}}
+		return
+	}
+	var reply *bot.OutgoingMessage
+	switch strings.ToLower(args[0]) {
+	case "list":
+		reply = handler.list(message)
+	case "cancel", "stop", "remove":
+		reply = cancel(args[1:], message)
+	default:
+		reply = handler.add(args, message)
+	}
+	c <- []*bot.OutgoingMessage{reply}
+}
+
+// before returns how long before an event reminders go out by default.
+func (handler Handler) before() time.Duration {
+	if handler.Before <= 0 {
+		return time.Hour
+	}
+	return handler.Before
+}
+
+// add saves a reminder of the event named by args[0], to go out the time given by args[1] before it starts.
+func (handler Handler) add(args []string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	before := handler.before()
+	if len(args) > 1 {
+		var err error
+		before, err = parseOffset(args[1])
+		if err != nil {
+			return &bot.OutgoingMessage{Text: err.Error()}
+		}
+	}
+	event, err := handler.Events.FindEvent(message.GroupID, args[0])
+	if err != nil {
+		return &bot.OutgoingMessage{Text: err.Error()}
+	}
+	now := time.Now()
+	start := event.StartIn(handler.Events.GroupTimezone(message.GroupID))
+	if !start.After(now) {
+		return &bot.OutgoingMessage{Text: "\"" + event.Title + "\" has already started."}
+	}
+	at := start.Add(-before)
+	if at.Before(now) {
+		return &bot.OutgoingMessage{Text: "\"" + event.Title + "\" starts in less than " + describeOffset(before) +
+			". Try a shorter time, like \"!remind " + args[0] + " 1h\"."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	err = sess.DB(DB).C(collection).Insert(reminder{
+		GroupID: message.GroupID,
+		UserID:  message.UserID,
+		Name:    message.Name,
+		Event:   event,
+		Before:  before,
+		At:      at,
+	})
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "I'll remind you " + describeOffset(before) + " before \"" + event.Title + "\"."}
+}
+
+// mine returns the sender's reminders in the group, soonest first.
+func mine(sess *mgo.Session, message bot.IncomingMessage) ([]reminder, error) {
+	var reminders []reminder
+	err := sess.DB(DB).C(collection).Find(bson.M{"group_id": message.GroupID, "user_id": message.UserID}).
+		Sort("at").All(&reminders)
+	return reminders, err
+}
+
+// list shows the sender's reminders in the group.
+func (handler Handler) list(message bot.IncomingMessage) *bot.OutgoingMessage {
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	reminders, err := mine(sess, message)
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	if len(reminders) == 0 {
+		return &bot.OutgoingMessage{Text: "You don't have any reminders. " + usage}
+	}
+	loc := handler.Events.GroupTimezone(message.GroupID)
+	lines := make([]string, len(reminders))
+	for i, r := range reminders {
+		lines[i] = fmt.Sprintf("%d. %s before %s", i+1, describeOffset(r.Before), events.FormatEvent(r.Event, loc))
+	}
+	return &bot.OutgoingMessage{Text: "Your reminders:\n" + strings.Join(lines, "\n")}
+}
+
+// cancel drops the sender's reminder with the given number in their list.
+func cancel(args []string, message bot.IncomingMessage) *bot.OutgoingMessage {
+	if len(args) < 1 {
+		return &bot.OutgoingMessage{Text: "Which reminder? See their numbers with \"!remind list\"."}
+	}
+	n, err := strconv.Atoi(args[0])
+	if err != nil || n < 1 {
+		return &bot.OutgoingMessage{Text: "Which reminder? See their numbers with \"!remind list\"."}
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	defer sess.Close()
+	reminders, err := mine(sess, message)
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	if n > len(reminders) {
+		return &bot.OutgoingMessage{Text: fmt.Sprintf("You don't have a reminder %d. See yours with \"!remind list\".", n)}
+	}
+	if err := sess.DB(DB).C(collection).RemoveId(reminders[n-1].ID); err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	return &bot.OutgoingMessage{Text: "Cancelled your reminder for \"" + reminders[n-1].Event.Title + "\"."}
+}
+
+// parseOffset reads a time before an event, like "30m", "2h", "1d" or "1w", or a combination like "1d12h".
+func parseOffset(value string) (time.Duration, error) {
+	units := map[string]time.Duration{"w": 7 * 24 * time.Hour, "d": 24 * time.Hour, "h": time.Hour, "m": time.Minute}
+	rest := strings.ToLower(value)
+	var total time.Duration
+	for rest != "" {
+		m := offsetPart.FindStringSubmatch(rest)
+		if m == nil {
+			return 0, errors.New("Couldn't understand \"" + value + "\". Use a time like 30m, 2h, 1d or 1w.")
+		}
+		n, _ := strconv.Atoi(m[1])
+		total += time.Duration(n) * units[m[2]]
+		rest = rest[len(m[0]):]
+	}
+	if total <= 0 {
+		return 0, errors.New("Reminders need to go out some time before the event, like 30m, 2h, 1d or 1w.")
+	}
+	return total, nil
+}
+
+// describeOffset renders a time before an event for people, e.g. "1 day" or "2 hours 30 minutes".
+func describeOffset(d time.Duration) string {
+	var parts []string
+	for _, unit := range []struct {
+		name string
+		size time.Duration
+	}{{"week", 7 * 24 * time.Hour}, {"day", 24 * time.Hour}, {"hour", time.Hour}, {"minute", time.Minute}} {
+		if n := int(d / unit.size); n > 0 {
+			part := strconv.Itoa(n) + " " + unit.name
+			if n > 1 {
+				part += "s"
+			}
+			parts = append(parts, part)
+			d -= time.Duration(n) * unit.size
+		}
+	}
+	if len(parts) == 0 {
+		return "a moment"
+	}
+	return strings.Join(parts, " ")
+}
+
+// SetupReminders starts checking every minute for reminders in groupID that are due, and posts them using botID. A bot
+// can only post in the group it was added to, so botID has to be that group's.
+func (handler Handler) SetupReminders(botID, groupID string) {
+	ticker := time.NewTicker(time.Minute)
+	go func(botID string) {
+		for range ticker.C {
+			handler.sendDue(botID, groupID, time.Now())
+		}
+	}(botID)
+}
+
+// sendDue posts every reminder in groupID whose time has come, and drops it. Reminders for events that have already
+// started, say because the bot was down, are dropped without being posted.
+func (handler Handler) sendDue(botID, groupID string, now time.Time) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	var due []reminder
+	if err := col.Find(bson.M{"group_id": groupID, "at": bson.M{"$lte": now}}).All(&due); err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, r := range due {
+		// The reminder is dropped first so that a failing post can't repeat it every minute.
+		if err := col.RemoveId(r.ID); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		m := reminderMessage(r, handler.Events.GroupTimezone(r.GroupID), now)
+		if m == nil {
+			continue
+		}
+		if _, err := bot.PostMessage(m, botID); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
+
+// reminderMessage mentions a reminder's owner with the event it's for and how soon it starts, reading the start in
+// loc. It returns nil when the event has already started at now.
+func reminderMessage(r reminder, loc *time.Location, now time.Time) *bot.OutgoingMessage {
+	start := r.Event.StartIn(loc)
+	if !start.After(now) {
+		return nil
+	}
+	m := mentions.New("")
+	m.Mention(mentions.User{ID: r.UserID, Name: r.Name})
+	m.WriteString(" reminder, starting in " + describeOffset(start.Sub(now).Round(time.Minute)) + ":\n" +
+		events.FormatEvent(r.Event, loc))
+	return m.Message()
+}
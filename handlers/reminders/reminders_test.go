@@ -0,0 +1,92 @@
+package reminders
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/events"
+)
+
+func TestParseOffset(t *testing.T) {
+	for _, test := range []struct {
+		value string
+		want  time.Duration
+		ok    bool
+	}{
+		{"30m", 30 * time.Minute, true},
+		{"2h", 2 * time.Hour, true},
+		{"1d", 24 * time.Hour, true},
+		{"1W", 7 * 24 * time.Hour, true},
+		{"1d12h", 36 * time.Hour, true},
+		{"1h30m", 90 * time.Minute, true},
+		{"0m", 0, false},
+		{"0d0h", 0, false},
+		{"abc", 0, false},
+		{"12", 0, false},
+		{"1d-2h", 0, false},
+		{"1 d", 0, false},
+	} {
+		got, err := parseOffset(test.value)
+		if (err == nil) != test.ok || got != test.want {
+			t.Errorf("parseOffset(%q) = %v, %v, want %v (ok %v)", test.value, got, err, test.want, test.ok)
+		}
+	}
+}
+
+func TestDescribeOffset(t *testing.T) {
+	for _, test := range []struct {
+		d    time.Duration
+		want string
+	}{
+		{time.Hour, "1 hour"},
+		{36 * time.Hour, "1 day 12 hours"},
+		{90 * time.Minute, "1 hour 30 minutes"},
+		{15*24*time.Hour + time.Minute, "2 weeks 1 day 1 minute"},
+		{30 * time.Second, "a moment"},
+		{0, "a moment"},
+	} {
+		if got := describeOffset(test.d); got != test.want {
+			t.Errorf("describeOffset(%v) = %q, want %q", test.d, got, test.want)
+		}
+	}
+}
+
+func TestReminderMessage(t *testing.T) {
+	chicago, err := time.LoadLocation("America/Chicago")
+	if err != nil {
+		t.Skip(err)
+	}
+	now := time.Date(2026, 10, 23, 12, 0, 0, 0, chicago)
+	r := reminder{
+		UserID: "12",
+		Name:   "Ann",
+		Event:  events.Event{Title: "Jazz night", Start: now.Add(90*time.Minute + 20*time.Second)},
+	}
+	m := reminderMessage(r, chicago, now)
+	if m == nil {
+		t.Fatal("no reminder for an event that hasn't started")
+	}
+	if !strings.HasPrefix(m.Text, "@Ann reminder, starting in 1 hour 30 minutes:\n") ||
+		!strings.Contains(m.Text, "Jazz night") {
+		t.Errorf("text = %q", m.Text)
+	}
+	if len(m.Attachments) != 1 || len(m.Attachments[0].UserIDs) != 1 || m.Attachments[0].UserIDs[0] != 12 {
+		t.Errorf("attachments = %+v, want a mention of user 12", m.Attachments)
+	}
+
+	r.Event.Start = now
+	if m := reminderMessage(r, chicago, now); m != nil {
+		t.Errorf("reminder for an event starting now = %q, want none", m.Text)
+	}
+
+	// All-day events start at midnight where the group is, not midnight UTC.
+	r.Event = events.Event{Title: "Street fair", Start: time.Date(2026, 10, 24, 0, 0, 0, 0, time.UTC), AllDay: true}
+	m = reminderMessage(r, chicago, now)
+	if m == nil || !strings.HasPrefix(m.Text, "@Ann reminder, starting in 12 hours:\n") {
+		t.Errorf("all-day reminder = %+v", m)
+	}
+	if m := reminderMessage(r, chicago, time.Date(2026, 10, 24, 0, 0, 0, 0, chicago)); m != nil {
+		t.Errorf("reminder for an all-day event that has begun = %q, want none", m.Text)
+	}
+}
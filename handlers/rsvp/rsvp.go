@@ -0,0 +1,270 @@
+/*
+Package rsvp keeps track of who in a group is going to the events the bot has posted. Events are picked by their number
+in the last list "!events" posted in the group, or by their ID: "!going 2", "!maybe 2" and "!notgoing 2" record the
+sender's response, and "!whosgoing 2" shows everyone's. "!whosgoing" on its own lists the upcoming events anyone has
+responded to.
+
+The day before an event, everyone who said they're going is mentioned in a reminder.
+
+Responses are kept in MongoDB, located with the MONGOLAB_URI and MONGOLAB_DB environment variables.
+*/
+package rsvp
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/events"
+	"github.com/sha1sum/distinguished_taste_society_bots/mentions"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Responses to an event.
+const (
+	Going    = "going"
+	Maybe    = "maybe"
+	NotGoing = "not going"
+)
+
+type (
+	// Handler records the sender's response to an event.
+	Handler struct {
+		// Events looks up the events being responded to
+		Events events.Handler
+		// Response is Going, Maybe or NotGoing
+		Response string
+	}
+
+	// ListHandler shows the responses to an event.
+	ListHandler struct {
+		// Events looks up the events asked about
+		Events events.Handler
+	}
+
+	// eventResponses holds a group's responses to one event.
+	eventResponses struct {
+		ID        bson.ObjectId `bson:"_id,omitempty"`
+		GroupID   string        `bson:"group_id"`
+		EventID   string        `bson:"event_id"`
+		Event     events.Event  `bson:"event"`
+		Responses []response    `bson:"responses"`
+		// Notified is set once the day-before mention has gone out
+		Notified bool `bson:"notified"`
+	}
+
+	// response is one person's response to an event.
+	response struct {
+		UserID string    `bson:"user_id"`
+		Name   string    `bson:"name"`
+		Status string    `bson:"status"`
+		At     time.Time `bson:"at"`
+	}
+)
+
+const (
+	collection = "groupmeEventRSVPsV1"
+	// allDaySlack is how far either side of a window events are looked up, since all-day events are saved starting at
+	// midnight UTC rather than midnight where the group is.
+	allDaySlack = 24 * time.Hour
+)
+
+// DB is the name of the MongoDB database
+var DB = handlers.DB
+
+// Handle records the sender's response to the event named in the message.
+func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" {
+		return
+	}
+	args := handlers.Arguments(message.Text, "going", "maybe", "notgoing")
+	if len(args) < 1 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{
+			Text: "Which event? Use its number in the last \"!events\" list, e.g. \"!going 2\".",
+		}}
+		return
+	}
+	event, err := handler.Events.FindEvent(message.GroupID, args[0])
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: err.Error()}}
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	selector := bson.M{"group_id": message.GroupID, "event_id": event.ID}
+	_, err = col.Upsert(selector, bson.M{
+		"$set":         bson.M{"event": event},
+		"$setOnInsert": bson.M{"notified": false},
+		"$pull":        bson.M{"responses": bson.M{"user_id": message.UserID}},
+	})
+	if err == nil {
+		err = col.Update(selector, bson.M{"$push": bson.M{"responses": response{
+			UserID: message.UserID,
+			Name:   message.Name,
+			Status: handler.Response,
+			At:     time.Now(),
+		}}})
+	}
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	text := "Got it, you're " + handler.Response + " to \"" + event.Title + "\"."
+	if handler.Response == Maybe {
+		text = "Got it, you might go to \"" + event.Title + "\"."
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: text}}
+}
+
+// Handle shows the responses to the event named in the message, or lists the upcoming events with responses when none
+// is named.
+func (handler ListHandler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" {
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	args := handlers.Arguments(message.Text, "whosgoing")
+	if len(args) < 1 {
+		c <- []*bot.OutgoingMessage{upcoming(col, message.GroupID, handler.Events.GroupTimezone(message.GroupID))}
+		return
+	}
+	event, err := handler.Events.FindEvent(message.GroupID, args[0])
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: err.Error()}}
+		return
+	}
+	var er eventResponses
+	err = col.Find(bson.M{"group_id": message.GroupID, "event_id": event.ID}).One(&er)
+	if err == mgo.ErrNotFound || err == nil && len(er.Responses) == 0 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: "Nobody has said whether they're going to \"" +
+			event.Title + "\" yet. Say so with \"!going " + args[0] + "\"."}}
+		return
+	}
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	lines := []string{events.FormatEvent(er.Event, handler.Events.GroupTimezone(message.GroupID))}
+	labels := map[string]string{Going: "Going", Maybe: "Maybe", NotGoing: "Not going"}
+	for _, status := range []string{Going, Maybe, NotGoing} {
+		var names []string
+		for _, r := range er.Responses {
+			if r.Status == status {
+				names = append(names, r.Name)
+			}
+		}
+		if len(names) > 0 {
+			lines = append(lines, fmt.Sprintf("%s (%d): %s", labels[status], len(names), strings.Join(names, ", ")))
+		}
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: strings.Join(lines, "\n")}}
+}
+
+// upcoming lists the group's upcoming events that anyone has responded to, with how many are going and their times in
+// loc.
+func upcoming(col *mgo.Collection, groupID string, loc *time.Location) *bot.OutgoingMessage {
+	now := time.Now()
+	var all []eventResponses
+	err := col.Find(bson.M{"group_id": groupID, "event.start": bson.M{"$gt": now.Add(-allDaySlack)}}).All(&all)
+	if err != nil {
+		return &bot.OutgoingMessage{Err: err}
+	}
+	sort.SliceStable(all, func(i, j int) bool { return all[i].Event.StartIn(loc).Before(all[j].Event.StartIn(loc)) })
+	var lines []string
+	for _, er := range all {
+		if !er.Event.StartIn(loc).After(now) {
+			continue
+		}
+		going, maybe := 0, 0
+		for _, r := range er.Responses {
+			switch r.Status {
+			case Going:
+				going++
+			case Maybe:
+				maybe++
+			}
+		}
+		if going+maybe == 0 {
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%s (%d going, %d maybe)", events.FormatEvent(er.Event, loc), going, maybe))
+	}
+	if len(lines) == 0 {
+		return &bot.OutgoingMessage{Text: "Nobody has said they're going to anything yet. Use \"!going <number>\" " +
+			"with an event from the last \"!events\" list."}
+	}
+	return &bot.OutgoingMessage{Text: "Upcoming plans:\n" + strings.Join(lines, "\n")}
+}
+
+// SetupDayBefore starts checking every ten minutes for events in groupID starting within a day, and mentions everyone
+// going to them using botID. A bot can only post in the group it was added to, so botID has to be that group's.
+func (handler ListHandler) SetupDayBefore(botID, groupID string) {
+	ticker := time.NewTicker(10 * time.Minute)
+	go func(botID string) {
+		for range ticker.C {
+			handler.notifyGoing(botID, groupID, time.Now())
+		}
+	}(botID)
+}
+
+// notifyGoing mentions the people going to each event in groupID that starts within a day of now, once per event.
+func (handler ListHandler) notifyGoing(botID, groupID string, now time.Time) {
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(collection)
+	var soon []eventResponses
+	err = col.Find(bson.M{
+		"group_id": groupID,
+		// Responses saved before the mention went out may not have the field at all.
+		"notified":    bson.M{"$ne": true},
+		"event.start": bson.M{"$gt": now.Add(-allDaySlack), "$lte": now.Add(24*time.Hour + allDaySlack)},
+	}).All(&soon)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	for _, er := range soon {
+		loc := handler.Events.GroupTimezone(er.GroupID)
+		if start := er.Event.StartIn(loc); !start.After(now) || start.After(now.Add(24*time.Hour)) {
+			continue
+		}
+		var going []mentions.User
+		for _, r := range er.Responses {
+			if r.Status == Going {
+				going = append(going, mentions.User{ID: r.UserID, Name: r.Name})
+			}
+		}
+		if len(going) == 0 {
+			continue
+		}
+		m := mentions.New("Coming up:\n" + events.FormatEvent(er.Event, loc) + "\n")
+		m.MentionAll(going)
+		if _, err := bot.PostMessage(m.Message(), botID); err != nil {
+			fmt.Println(err)
+			continue
+		}
+		// Until someone is going there's nobody to mention, so the event is checked again next time.
+		if err := col.UpdateId(er.ID, bson.M{"$set": bson.M{"notified": true}}); err != nil {
+			fmt.Println(err)
+		}
+	}
+}
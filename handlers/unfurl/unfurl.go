@@ -0,0 +1,338 @@
+/*
+Package unfurl watches for links posted in a GroupMe group and replies with the title and domain of each linked page,
+using the same page metadata that news previews are built from.
+
+Unfurling is opt-in: a group turns it on with "!unfurl on" (and back off with "!unfurl off"). Domains can be limited
+with "!unfurl allow <domain>" and "!unfurl deny <domain>", undone with "!unfurl clear <domain>", and the current
+settings shown with "!unfurl status". Once a link has been unfurled in a group it won't be unfurled there again until
+the handler's Window has passed.
+*/
+package unfurl
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers"
+	"github.com/sha1sum/distinguished_taste_society_bots/matchers"
+	"github.com/sha1sum/golang_groupme_bot/bot"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Handler will satisfy the bot.Handler interface. It's meant to be triggered by every message containing a link.
+type Handler struct {
+	// Deny is a list of domains that are never unfurled in any group
+	Deny []string
+	// Window is how long a link is left alone after it's been unfurled in a group
+	Window time.Duration
+	// MaxLinks is the most links unfurled from a single message
+	MaxLinks int
+}
+
+// SettingsHandler will satisfy the bot.Handler interface for the "!unfurl" settings trigger.
+type SettingsHandler struct{}
+
+// groupSettings is a group's stored unfurl configuration.
+type groupSettings struct {
+	GroupID string   `bson:"group_id"`
+	Enabled bool     `bson:"enabled"`
+	Allow   []string `bson:"allow"`
+	Deny    []string `bson:"deny"`
+}
+
+// unfurled records when a link was last unfurled in a group.
+type unfurled struct {
+	GroupID    string    `bson:"group_id"`
+	URL        string    `bson:"url"`
+	UnfurledAt time.Time `bson:"unfurled_at"`
+}
+
+const (
+	settingsCollection = "groupmeUnfurlGroupsV1"
+	unfurledCollection = "groupmeUnfurlsV1"
+	usage              = "Use \"!unfurl on\", \"!unfurl off\", \"!unfurl allow <domain>\", \"!unfurl deny <domain>\", " +
+		"\"!unfurl clear <domain>\" or \"!unfurl status\"."
+)
+
+// linkPattern finds web addresses in message text.
+var linkPattern = regexp.MustCompile(`https?://[^\s<>"]+`)
+
+// DB is the name of the MongoDB database
+var DB = handlers.DB
+
+// Handle unfurls the links in a message, as long as its group has opted in.
+func (handler Handler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" || message.System {
+		c <- nil
+		return
+	}
+	// Commands are left to their own handlers, even when they include a link.
+	text := strings.TrimSpace(message.Text)
+	if strings.HasPrefix(text, "!") {
+		c <- nil
+		return
+	}
+	links := findLinks(text, handler.maxLinks())
+	if len(links) < 1 {
+		c <- nil
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		fmt.Println(err)
+		c <- nil
+		return
+	}
+	defer sess.Close()
+	settings, err := findSettings(sess.DB(DB).C(settingsCollection), message.GroupID)
+	if err != nil || !settings.Enabled {
+		c <- nil
+		return
+	}
+	recent := sess.DB(DB).C(unfurledCollection)
+	lines := make([]string, 0)
+	for _, link := range links {
+		host := linkHost(link)
+		if host == "" || !handler.permitted(host, settings) || handler.recentlyUnfurled(recent, message.GroupID, link) {
+			continue
+		}
+		page, err := matchers.RetrievePage(link)
+		if err != nil {
+			fmt.Println("Couldn't unfurl", link, err)
+			continue
+		}
+		if page.Title == "" {
+			continue
+		}
+		_, err = recent.Upsert(bson.M{"group_id": message.GroupID, "url": link}, bson.M{
+			"$set": bson.M{"unfurled_at": time.Now()},
+		})
+		if err != nil {
+			fmt.Println(err)
+		}
+		lines = append(lines, page.Title+" ("+host+")")
+	}
+	forgetExpired(recent, handler.window())
+	if len(lines) < 1 {
+		c <- nil
+		return
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: strings.Join(lines, "\n")}}
+}
+
+// maxLinks returns the most links to unfurl from one message.
+func (handler Handler) maxLinks() int {
+	if handler.MaxLinks < 1 {
+		return 3
+	}
+	return handler.MaxLinks
+}
+
+// window returns how long to leave a link alone after unfurling it.
+func (handler Handler) window() time.Duration {
+	if handler.Window <= 0 {
+		return 24 * time.Hour
+	}
+	return handler.Window
+}
+
+// permitted checks a link's host against the handler's denylist and the group's allow and deny lists. When a group
+// has an allowlist, only the domains on it are unfurled.
+func (handler Handler) permitted(host string, settings groupSettings) bool {
+	for _, d := range handler.Deny {
+		if hostMatches(host, d) {
+			return false
+		}
+	}
+	for _, d := range settings.Deny {
+		if hostMatches(host, d) {
+			return false
+		}
+	}
+	if len(settings.Allow) < 1 {
+		return true
+	}
+	for _, d := range settings.Allow {
+		if hostMatches(host, d) {
+			return true
+		}
+	}
+	return false
+}
+
+// recentlyUnfurled reports whether the link was unfurled in the group within the handler's window.
+func (handler Handler) recentlyUnfurled(col *mgo.Collection, groupID, link string) bool {
+	var u unfurled
+	err := col.Find(bson.M{
+		"group_id":    groupID,
+		"url":         link,
+		"unfurled_at": bson.M{"$gt": time.Now().Add(-handler.window())},
+	}).One(&u)
+	return err == nil
+}
+
+// forgetExpired deletes the records of links unfurled longer than window ago, in every group, since they no longer
+// hold anything back. Without it the collection would grow by a record for every link ever unfurled.
+func forgetExpired(col *mgo.Collection, window time.Duration) {
+	_, err := col.RemoveAll(bson.M{"unfurled_at": bson.M{"$lte": time.Now().Add(-window)}})
+	if err != nil {
+		fmt.Println(err)
+	}
+}
+
+// findLinks returns up to max distinct links from text, with any trailing punctuation from the sentence around them
+// trimmed off.
+func findLinks(text string, max int) []string {
+	links := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, l := range linkPattern.FindAllString(text, -1) {
+		l = trimLink(l)
+		if seen[l] {
+			continue
+		}
+		seen[l] = true
+		links = append(links, l)
+		if len(links) >= max {
+			break
+		}
+	}
+	return links
+}
+
+// trimLink drops the punctuation at the end of a link that belongs to the sentence around it. A closing bracket is
+// kept when the link has the one it closes, as in https://en.wikipedia.org/wiki/Bash_(Unix_shell).
+func trimLink(link string) string {
+	for link != "" {
+		var open string
+		switch link[len(link)-1] {
+		case '.', ',', ';', ':', '!', '?', '\'':
+		case ')':
+			open = "("
+		case ']':
+			open = "["
+		case '}':
+			open = "{"
+		default:
+			return link
+		}
+		if open != "" && strings.Count(link, open) >= strings.Count(link, link[len(link)-1:]) {
+			return link
+		}
+		link = link[:len(link)-1]
+	}
+	return link
+}
+
+// linkHost returns the lowercased host of a link without any "www." prefix.
+func linkHost(link string) string {
+	parsed, err := url.Parse(link)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimPrefix(strings.ToLower(parsed.Host), "www.")
+}
+
+// hostMatches reports whether host is domain or one of its subdomains.
+func hostMatches(host, domain string) bool {
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+// Handle changes the unfurl settings for the group the message was posted in.
+func (handler SettingsHandler) Handle(term string, c chan []*bot.OutgoingMessage, message bot.IncomingMessage) {
+	if message.SenderType == "bot" {
+		return
+	}
+	words := strings.Fields(term)
+	if len(words) < 1 {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: usage}}
+		return
+	}
+	sess, err := handlers.Dial()
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	defer sess.Close()
+	col := sess.DB(DB).C(settingsCollection)
+	var text string
+	switch words[0] {
+	case "on":
+		err = updateSettings(col, message.GroupID, bson.M{"$set": bson.M{"enabled": true}})
+		text = "Links posted here will now be unfurled."
+	case "off":
+		err = updateSettings(col, message.GroupID, bson.M{"$set": bson.M{"enabled": false}})
+		text = "Links posted here will no longer be unfurled."
+	case "allow", "deny", "clear":
+		if len(words) < 2 {
+			err = errors.New("You need to say which domain.")
+			break
+		}
+		domain := strings.TrimPrefix(words[1], "www.")
+		switch words[0] {
+		case "allow":
+			err = updateSettings(col, message.GroupID, bson.M{
+				"$addToSet": bson.M{"allow": domain},
+				"$pull":     bson.M{"deny": domain},
+			})
+			text = "Links to " + domain + " will be unfurled."
+		case "deny":
+			err = updateSettings(col, message.GroupID, bson.M{
+				"$addToSet": bson.M{"deny": domain},
+				"$pull":     bson.M{"allow": domain},
+			})
+			text = "Links to " + domain + " won't be unfurled."
+		default:
+			err = updateSettings(col, message.GroupID, bson.M{
+				"$pull": bson.M{"allow": domain, "deny": domain},
+			})
+			text = domain + " has been taken off the allow and deny lists."
+		}
+	case "status":
+		var settings groupSettings
+		settings, err = findSettings(col, message.GroupID)
+		text = describe(settings)
+	default:
+		text = usage
+	}
+	if err != nil {
+		c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Err: err}}
+		return
+	}
+	c <- []*bot.OutgoingMessage{&bot.OutgoingMessage{Text: text}}
+}
+
+// describe summarizes a group's unfurl settings.
+func describe(settings groupSettings) string {
+	text := "Link unfurling is off."
+	if settings.Enabled {
+		text = "Link unfurling is on."
+	}
+	if len(settings.Allow) > 0 {
+		text += " Only unfurling: " + strings.Join(settings.Allow, ", ") + "."
+	}
+	if len(settings.Deny) > 0 {
+		text += " Never unfurling: " + strings.Join(settings.Deny, ", ") + "."
+	}
+	return text
+}
+
+// findSettings loads a group's settings. A group with no stored settings has unfurling off.
+func findSettings(col *mgo.Collection, groupID string) (groupSettings, error) {
+	var settings groupSettings
+	err := col.Find(bson.M{"group_id": groupID}).One(&settings)
+	if err == mgo.ErrNotFound {
+		return groupSettings{GroupID: groupID}, nil
+	}
+	return settings, err
+}
+
+// updateSettings applies a change to a group's settings, creating them if needed.
+func updateSettings(col *mgo.Collection, groupID string, change bson.M) error {
+	_, err := col.Upsert(bson.M{"group_id": groupID}, change)
+	return err
+}
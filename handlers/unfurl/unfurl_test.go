@@ -0,0 +1,72 @@
+package unfurl
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFindLinks(t *testing.T) {
+	for _, test := range []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{"no links here", 3, []string{}},
+		{"see https://example.com/a.", 3, []string{"https://example.com/a"}},
+		{"really? https://example.com/a?!", 3, []string{"https://example.com/a"}},
+		{"(like http://example.com/a)", 3, []string{"http://example.com/a"}},
+		{"https://en.wikipedia.org/wiki/Bash_(Unix_shell)", 3, []string{"https://en.wikipedia.org/wiki/Bash_(Unix_shell)"}},
+		{"(see https://en.wikipedia.org/wiki/Bash_(Unix_shell)).", 3,
+			[]string{"https://en.wikipedia.org/wiki/Bash_(Unix_shell)"}},
+		{"[https://example.com/list[1]]", 3, []string{"https://example.com/list[1]"}},
+		{"'https://example.com/it's'", 3, []string{"https://example.com/it's"}},
+		{`<a href="https://example.com/q?x=1&y=2">`, 3, []string{"https://example.com/q?x=1&y=2"}},
+		{"https://example.com/a https://example.com/a, and https://example.com/b", 3,
+			[]string{"https://example.com/a", "https://example.com/b"}},
+		{"https://a.com https://b.com https://c.com https://d.com", 2, []string{"https://a.com", "https://b.com"}},
+		{"ftp://example.com/file and mailto:someone@example.com", 3, []string{}},
+	} {
+		if got := findLinks(test.text, test.max); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("findLinks(%q, %d) = %q, want %q", test.text, test.max, got, test.want)
+		}
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	for _, test := range []struct {
+		host, domain string
+		want         bool
+	}{
+		{"example.com", "example.com", true},
+		{"news.example.com", "example.com", true},
+		{"a.b.example.com", "example.com", true},
+		{"badexample.com", "example.com", false},
+		{"example.com.evil.net", "example.com", false},
+		{"example.com", "news.example.com", false},
+	} {
+		if got := hostMatches(test.host, test.domain); got != test.want {
+			t.Errorf("hostMatches(%q, %q) = %v, want %v", test.host, test.domain, got, test.want)
+		}
+	}
+}
+
+func TestPermitted(t *testing.T) {
+	handler := Handler{Deny: []string{"tracker.net"}}
+	for _, test := range []struct {
+		host     string
+		settings groupSettings
+		want     bool
+	}{
+		{"example.com", groupSettings{}, true},
+		{"ads.tracker.net", groupSettings{}, false},
+		{"ads.tracker.net", groupSettings{Allow: []string{"tracker.net"}}, false},
+		{"example.com", groupSettings{Deny: []string{"example.com"}}, false},
+		{"news.example.com", groupSettings{Allow: []string{"example.com"}}, true},
+		{"other.org", groupSettings{Allow: []string{"example.com"}}, false},
+		{"news.example.com", groupSettings{Allow: []string{"example.com"}, Deny: []string{"news.example.com"}}, false},
+	} {
+		if got := handler.permitted(test.host, test.settings); got != test.want {
+			t.Errorf("permitted(%q, %+v) = %v, want %v", test.host, test.settings, got, test.want)
+		}
+	}
+}
@@ -1,16 +1,36 @@
 /*
 Distinguished Taste Society Bots are a set of bots used by the Distinguished Taste Society on their GroupMe groups. The
 bots are built to use github.com/sha1sum/golang_groupme_bot.
+
+Run with no arguments, the bots start listening for GroupMe callbacks. A few admin tasks can be run instead by passing
+a subcommand:
+
+	distinguished_taste_society_bots opml import <group_id> <file>
+	distinguished_taste_society_bots opml export <group_id> [file]
+
+The same OPML import and export is served over HTTP at /admin/feeds/opml when ADMIN_PORT and ADMIN_TOKEN are set.
+
+Anything posted on a schedule, like digests, feed items, new events and reminders, is only posted for the group in
+GROUPME_GROUP_ID, which should be the group the bot in GROUPME_BOT_ID belongs to.
 */
 
 package main
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"time"
 
+	"github.com/sha1sum/distinguished_taste_society_bots/groupme"
 	"github.com/sha1sum/distinguished_taste_society_bots/handlers/adultpoints"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/digest"
 	"github.com/sha1sum/distinguished_taste_society_bots/handlers/events"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/feeds"
 	"github.com/sha1sum/distinguished_taste_society_bots/handlers/googlenews"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/reminders"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/rsvp"
+	"github.com/sha1sum/distinguished_taste_society_bots/handlers/unfurl"
 	"github.com/sha1sum/golang_groupme_bot/bot"
 )
 
@@ -25,7 +45,7 @@ func main() {
 			// exclamation points
 			"! news",
 		},
-		Handler: new(googlenews.Handler),
+		Handler: googlenews.Handler{Previews: true, Images: groupme.NewImageService()},
 		BotID:   os.Getenv("GROUPME_BOT_ID"),
 	}
 
@@ -45,8 +65,57 @@ func main() {
 		BotID:   os.Getenv("GROUPME_BOT_ID"),
 	}
 
+	// Daily news digest bot
+	digestHandler := digest.Handler{Stories: 3, Timezone: "America/New_York"}
+	digestBot := bot.Command{
+		Triggers: []string{
+			"!digest",
+			"! digest",
+		},
+		Handler: digestHandler,
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+
+	// Feed subscription bot
+	feedsHandler := feeds.Handler{Interval: 30 * time.Minute, MaxAnnounce: 3}
+	feedBot := bot.Command{
+		Triggers: []string{
+			"!feed",
+			"! feed",
+		},
+		Handler: feedsHandler,
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+
 	// Event Search bot
-	eventsHandler := events.Handler{Key: os.Getenv("EVENTFUL_API_KEY"), ZIP: "33701"}
+	eventsLocation := os.Getenv("EVENTS_LOCATION")
+	if eventsLocation == "" {
+		eventsLocation = "33701"
+	}
+	eventsProvider, err := events.NewProvider(os.Getenv("EVENTS_PROVIDER"))
+	if err != nil {
+		fmt.Println(err)
+	}
+	eventsNarrowing, err := events.NewNarrowing()
+	if err != nil {
+		fmt.Println(err)
+	}
+	// Venue and picture attachments are on unless EVENTS_ATTACHMENTS turns them off, e.g. with "false".
+	eventsAttachments := true
+	if value := os.Getenv("EVENTS_ATTACHMENTS"); value != "" {
+		if eventsAttachments, err = strconv.ParseBool(value); err != nil {
+			fmt.Println("EVENTS_ATTACHMENTS:", err)
+		}
+	}
+	eventsHandler := events.Handler{
+		Provider:    eventsProvider,
+		Location:    eventsLocation,
+		Calendar:    groupme.NewCalendarService(),
+		Attachments: eventsAttachments,
+		Images:      groupme.NewImageService(),
+		Home:        os.Getenv("EVENTS_HOME"),
+		Narrow:      eventsNarrowing,
+	}
 	eventBot := bot.Command{
 		Triggers: []string{
 			"!events",
@@ -56,11 +125,105 @@ func main() {
 		BotID:   os.Getenv("GROUPME_BOT_ID"),
 	}
 
+	// Event reminder bot
+	remindersHandler := reminders.Handler{Events: eventsHandler, Before: time.Hour}
+	remindBot := bot.Command{
+		Triggers: []string{
+			"!remind",
+			"! remind",
+		},
+		Handler: remindersHandler,
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+
+	// Event RSVP bots
+	goingBot := bot.Command{
+		Triggers: []string{
+			"!going",
+			"! going",
+		},
+		Handler: rsvp.Handler{Events: eventsHandler, Response: rsvp.Going},
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+	maybeBot := bot.Command{
+		Triggers: []string{
+			"!maybe",
+			"! maybe",
+		},
+		Handler: rsvp.Handler{Events: eventsHandler, Response: rsvp.Maybe},
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+	notGoingBot := bot.Command{
+		Triggers: []string{
+			"!notgoing",
+			"! notgoing",
+		},
+		Handler: rsvp.Handler{Events: eventsHandler, Response: rsvp.NotGoing},
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+	whosGoingHandler := rsvp.ListHandler{Events: eventsHandler}
+	whosGoingBot := bot.Command{
+		Triggers: []string{
+			"!whosgoing",
+			"! whosgoing",
+		},
+		Handler: whosGoingHandler,
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+
+	// Link unfurling settings bot
+	unfurlSettings := bot.Command{
+		Triggers: []string{
+			"!unfurl",
+			"! unfurl",
+		},
+		Handler: new(unfurl.SettingsHandler),
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+
+	// Link unfurling bot, which looks at every message with a link in it rather than a command
+	unfurlBot := bot.Command{
+		Triggers: []string{
+			"http",
+		},
+		Handler: unfurl.Handler{Deny: []string{"groupme.com"}, Window: 24 * time.Hour},
+		BotID:   os.Getenv("GROUPME_BOT_ID"),
+	}
+
 	commands = append(commands, news)
 	commands = append(commands, adult)
+	commands = append(commands, digestBot)
+	commands = append(commands, feedBot)
 	commands = append(commands, eventBot)
+	commands = append(commands, remindBot)
+	commands = append(commands, goingBot)
+	commands = append(commands, maybeBot)
+	commands = append(commands, notGoingBot)
+	commands = append(commands, whosGoingBot)
+	commands = append(commands, unfurlSettings)
+	// The link unfurler needs to stay last so that every other command gets a look at a message first.
+	commands = append(commands, unfurlBot)
+
+	// Anything on the command line is an admin subcommand rather than a request to start the bots.
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:], feedsHandler))
+	}
 
-	eventsHandler.SetupSearch(eventBot.BotID)
+	// The admin endpoints get their own port, since the bot listener owns the main one.
+	if port := os.Getenv("ADMIN_PORT"); port != "" {
+		go serveAdmin(":"+port, os.Getenv("ADMIN_TOKEN"), feedsHandler)
+	}
+
+	// A bot can only post in the group it was added to, so scheduled posts are only made for that group.
+	if botGroup := os.Getenv("GROUPME_GROUP_ID"); botGroup != "" {
+		eventsHandler.SetupSearch(eventBot.BotID, botGroup)
+		digestHandler.SetupDigest(digestBot.BotID, botGroup)
+		feedsHandler.SetupPolling(feedBot.BotID, botGroup)
+		remindersHandler.SetupReminders(remindBot.BotID, botGroup)
+		whosGoingHandler.SetupDayBefore(goingBot.BotID, botGroup)
+	} else {
+		fmt.Println("GROUPME_GROUP_ID is not set, so nothing will be posted on a schedule")
+	}
 
 	bot.Listen(commands)
 }
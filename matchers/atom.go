@@ -0,0 +1,212 @@
+package matchers
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+type (
+	// atomLink defines the fields associated with the link tag
+	// in the atom document.
+	atomLink struct {
+		Href   string `xml:"href,attr"`
+		Rel    string `xml:"rel,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
+	}
+
+	// atomPerson defines the fields associated with the author tag
+	// in the atom document.
+	atomPerson struct {
+		Name  string `xml:"name"`
+		Email string `xml:"email"`
+	}
+
+	// atomCategory defines the fields associated with the category tag
+	// in the atom document.
+	atomCategory struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	}
+
+	// atomEntry defines the fields associated with the entry tag
+	// in the atom document. It's decoded by UnmarshalXML.
+	atomEntry struct {
+		ID         string
+		Title      string
+		Links      []atomLink
+		Published  string
+		Updated    string
+		Authors    []atomPerson
+		Categories []atomCategory
+		Summary    string
+		Content    string
+	}
+
+	// atomText is a text construct, like an entry's title or content, which can hold plain text, escaped HTML or
+	// inline XHTML.
+	atomText struct {
+		Type  string `xml:"type,attr"`
+		Text  string `xml:",chardata"`
+		Inner string `xml:",innerxml"`
+	}
+
+	// atomDocument defines the fields associated with the atom document.
+	atomDocument struct {
+		XMLName  xml.Name     `xml:"feed"`
+		Title    string       `xml:"title"`
+		Subtitle string       `xml:"subtitle"`
+		Updated  string       `xml:"updated"`
+		Links    []atomLink   `xml:"link"`
+		Authors  []atomPerson `xml:"author"`
+		Entries  []atomEntry  `xml:"entry"`
+	}
+)
+
+// UnmarshalXML decodes an entry, matching elements by namespace as well as name so that extension elements like
+// <media:title> don't overwrite the entry's own.
+func (entry *atomEntry) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if t.Name.Space != "" && t.Name.Space != start.Name.Space {
+				err = d.Skip()
+			} else {
+				err = entry.decodeChild(d, t)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decodeChild decodes one of an entry's child elements into the matching field, skipping anything unrecognized.
+func (entry *atomEntry) decodeChild(d *xml.Decoder, child xml.StartElement) error {
+	var err error
+	switch child.Name.Local {
+	case "id":
+		entry.ID, err = textOf(d, child)
+	case "title":
+		entry.Title, err = atomTextOf(d, child)
+	case "link":
+		var l atomLink
+		err = d.DecodeElement(&l, &child)
+		entry.Links = append(entry.Links, l)
+	case "published":
+		entry.Published, err = textOf(d, child)
+	case "updated":
+		entry.Updated, err = textOf(d, child)
+	case "author":
+		var a atomPerson
+		err = d.DecodeElement(&a, &child)
+		entry.Authors = append(entry.Authors, a)
+	case "category":
+		var c atomCategory
+		err = d.DecodeElement(&c, &child)
+		entry.Categories = append(entry.Categories, c)
+	case "summary":
+		entry.Summary, err = atomTextOf(d, child)
+	case "content":
+		entry.Content, err = atomTextOf(d, child)
+	default:
+		err = d.Skip()
+	}
+	return err
+}
+
+// atomTextOf decodes a text construct. Inline XHTML is kept as markup, without the <div> Atom requires around it;
+// anything else is its text, which for escaped HTML is the markup itself.
+func atomTextOf(d *xml.Decoder, start xml.StartElement) (string, error) {
+	var text atomText
+	if err := d.DecodeElement(&text, &start); err != nil {
+		return "", err
+	}
+	if text.Type != "xhtml" {
+		return text.Text, nil
+	}
+	inner := strings.TrimSpace(text.Inner)
+	open, end := strings.Index(inner, ">"), strings.LastIndex(inner, "</")
+	if !strings.HasPrefix(inner, "<") || !strings.HasSuffix(inner, "div>") || open < 0 || open > end {
+		return inner, nil
+	}
+	if name := strings.Fields(inner[1:open]); len(name) > 0 && strings.HasSuffix(name[0], "div") {
+		inner = inner[open+1 : end]
+	}
+	return inner, nil
+}
+
+// parseAtom decodes an Atom 1.0 document into a Feed.
+func parseAtom(data []byte) (*Feed, error) {
+	var document atomDocument
+	err := newDecoder(data).Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	feed := &Feed{
+		Format:      FormatAtom,
+		Title:       strings.TrimSpace(document.Title),
+		Link:        alternateLink(document.Links),
+		Description: strings.TrimSpace(document.Subtitle),
+		Updated:     parseTimeOrZero(document.Updated),
+		Items:       make([]FeedItem, 0, len(document.Entries)),
+	}
+	for _, entry := range document.Entries {
+		fi := FeedItem{
+			ID:          strings.TrimSpace(entry.ID),
+			Title:       strings.TrimSpace(entry.Title),
+			Link:        alternateLink(entry.Links),
+			Description: strings.TrimSpace(entry.Summary),
+			Content:     strings.TrimSpace(entry.Content),
+			Published:   parseTimeOrZero(entry.Published),
+			Updated:     parseTimeOrZero(entry.Updated),
+		}
+		if fi.ID == "" {
+			fi.ID = fi.Link
+		}
+		if fi.Published.IsZero() {
+			fi.Published = fi.Updated
+		}
+		// Entries inherit the feed's authors when they don't name their own.
+		authors := entry.Authors
+		if len(authors) < 1 {
+			authors = document.Authors
+		}
+		for _, a := range authors {
+			if name := strings.TrimSpace(a.Name); name != "" {
+				fi.Authors = append(fi.Authors, name)
+			}
+		}
+		for _, c := range entry.Categories {
+			if term := strings.TrimSpace(c.Term); term != "" {
+				fi.Categories = append(fi.Categories, term)
+			}
+		}
+		for _, l := range entry.Links {
+			if l.Rel != "enclosure" {
+				continue
+			}
+			length, _ := strconv.ParseInt(strings.TrimSpace(l.Length), 10, 64)
+			fi.Enclosures = append(fi.Enclosures, Enclosure{URL: l.Href, Type: l.Type, Length: length})
+		}
+		feed.Items = append(feed.Items, fi)
+	}
+	return feed, nil
+}
+
+// alternateLink picks the link to the HTML version of an Atom feed or entry. A link with no rel counts as alternate.
+func alternateLink(links []atomLink) string {
+	for _, l := range links {
+		if l.Rel == "" || l.Rel == "alternate" {
+			return strings.TrimSpace(l.Href)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,98 @@
+package matchers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// windows1252 holds the characters Windows-1252 puts in the 0x80-0x9F range, where ISO-8859-1 has control codes.
+// Unassigned bytes map to the Unicode replacement character.
+var windows1252 = [32]rune{
+	'€', '�', '‚', 'ƒ', '„', '…', '†', '‡',
+	'ˆ', '‰', 'Š', '‹', 'Œ', '�', 'Ž', '�',
+	'�', '‘', '’', '“', '”', '•', '–', '—',
+	'˜', '™', 'š', '›', 'œ', '�', 'ž', 'Ÿ',
+}
+
+// CharsetReader converts a document in the named character set to UTF-8, for use as an xml.Decoder's CharsetReader.
+// UTF-8, US-ASCII, ISO-8859-1 and Windows-1252 are supported; ISO-8859-1 is decoded as Windows-1252, as browsers
+// do, since feeds that claim Latin-1 are usually really Windows-1252.
+func CharsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(strings.TrimSpace(charset)) {
+	case "", "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "latin-1", "l1", "windows-1252", "cp1252", "x-cp1252":
+		return &singleByteReader{r: bufio.NewReader(input)}, nil
+	}
+	return nil, fmt.Errorf("Unsupported character set %q", charset)
+}
+
+// xmlEncoding matches the encoding in a document's XML declaration.
+var xmlEncoding = regexp.MustCompile(`^(\s*<\?xml[^>]*?encoding\s*=\s*)("[^"]*"|'[^']*')`)
+
+// withCharset converts a document to UTF-8 from the character set named in the Content-Type header it was sent with,
+// which takes precedence over the document's own XML declaration, and updates the declaration to match. Documents
+// sent without a charset, or with one CharsetReader doesn't support, are left for their declaration to describe.
+func withCharset(data []byte, charset string) []byte {
+	if charset == "" {
+		return data
+	}
+	r, err := CharsetReader(charset, bytes.NewReader(data))
+	if err != nil {
+		return data
+	}
+	converted, err := ioutil.ReadAll(r)
+	if err != nil {
+		return data
+	}
+	return xmlEncoding.ReplaceAll(converted, []byte(`${1}"UTF-8"`))
+}
+
+// singleByteReader decodes Windows-1252 to UTF-8.
+type singleByteReader struct {
+	r       *bufio.Reader
+	pending []byte
+}
+
+// Read fills p with UTF-8, holding on to any part of a character that doesn't fit until the next call.
+func (s *singleByteReader) Read(p []byte) (int, error) {
+	n := 0
+	for n < len(p) {
+		if len(s.pending) > 0 {
+			c := copy(p[n:], s.pending)
+			s.pending = s.pending[c:]
+			n += c
+			continue
+		}
+		b, err := s.r.ReadByte()
+		if err != nil {
+			if n > 0 {
+				return n, nil
+			}
+			return 0, err
+		}
+		r := rune(b)
+		if b >= 0x80 && b <= 0x9f {
+			r = windows1252[b-0x80]
+		}
+		var buf [utf8.UTFMax]byte
+		s.pending = buf[:utf8.EncodeRune(buf[:], r)]
+	}
+	return n, nil
+}
+
+// newDecoder sets up an XML decoder for a feed document. Non-UTF-8 documents are converted through CharsetReader,
+// and the HTML entities that feeds often use without declaring (like &nbsp;) are understood.
+func newDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = CharsetReader
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
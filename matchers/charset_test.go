@@ -0,0 +1,42 @@
+package matchers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRetrieveFeedHeaderCharset(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// The header says Windows-1252 while the declaration claims UTF-8; the header wins.
+		w.Header().Set("Content-Type", "application/rss+xml; charset=windows-1252")
+		w.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?><rss><channel><title>Caf` + "\xe9 \x93news\x94" +
+			`</title></channel></rss>`))
+	}))
+	defer server.Close()
+	c := NewClient()
+	c.HTTPClient = server.Client()
+	feed, err := c.RetrieveFeed(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Café “news”"; feed.Title != want {
+		t.Errorf("title = %q, want %q", feed.Title, want)
+	}
+}
+
+func TestWithCharset(t *testing.T) {
+	for _, test := range []struct {
+		data, charset, want string
+	}{
+		{`<?xml version="1.0" encoding="iso-8859-1"?><a>` + "\xe9" + `</a>`, "ISO-8859-1",
+			`<?xml version="1.0" encoding="UTF-8"?><a>é</a>`},
+		{`<?xml version='1.0' encoding='iso-8859-1'?><a/>`, "utf-8", `<?xml version='1.0' encoding="UTF-8"?><a/>`},
+		{`<?xml version="1.0" encoding="iso-8859-1"?><a/>`, "", `<?xml version="1.0" encoding="iso-8859-1"?><a/>`},
+		{`<?xml version="1.0" encoding="shift_jis"?><a/>`, "shift_jis", `<?xml version="1.0" encoding="shift_jis"?><a/>`},
+	} {
+		if got := string(withCharset([]byte(test.data), test.charset)); got != test.want {
+			t.Errorf("withCharset(%q, %q) = %q, want %q", test.data, test.charset, got, test.want)
+		}
+	}
+}
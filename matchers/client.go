@@ -0,0 +1,207 @@
+package matchers
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// DefaultUserAgent is the User-Agent header sent with every request made by this package.
+const DefaultUserAgent = "DistinguishedTasteSocietyBots/1.0 (+https://github.com/sha1sum/distinguished_taste_society_bots)"
+
+// Client fetches feeds over HTTP. Each request is limited by a timeout and a maximum body size, and responses are
+// cached in-process by URL. A cached response is served as-is until its TTL runs out, after which the feed is
+// requested again with If-None-Match/If-Modified-Since so that an unchanged feed costs nothing more than a 304.
+//
+// The zero value isn't usable; create a Client with NewClient. Tests can swap in a fake transport through
+// HTTPClient.Transport.
+type Client struct {
+	// HTTPClient makes the requests. Its Timeout bounds each request.
+	HTTPClient *http.Client
+	// MaxBodySize is the largest response body that will be read, in bytes
+	MaxBodySize int64
+	// UserAgent is sent with every request
+	UserAgent string
+	// TTL is how long a response is served from the cache before the feed is checked again. Zero turns caching off.
+	TTL time.Duration
+	// MaxEntries caps the number of URLs kept in the cache. The least recently fetched entry is dropped first.
+	MaxEntries int
+	// MaxCacheSize caps the total size of the bodies kept in the cache, in bytes, in the same way. Bodies bigger than
+	// it aren't cached at all. Zero means no cap.
+	MaxCacheSize int64
+
+	mu    sync.Mutex
+	cache map[string]*cacheEntry
+	// size is the total size of the bodies in cache
+	size int64
+}
+
+// cacheEntry is a cached response for one URL.
+type cacheEntry struct {
+	body         []byte
+	etag         string
+	lastModified string
+	// charset is the character set named in the response's Content-Type header, if any
+	charset string
+	fetched time.Time
+}
+
+// DefaultClient is the Client used by Retrieve and RetrieveFeed.
+var DefaultClient = NewClient()
+
+// NewClient creates a Client with a 15 second timeout, a 5MB body limit and a five minute cache of up to 32MB. Feeds
+// and calendars are added by anyone in a group, so like pages they're only fetched from public addresses.
+func NewClient() *Client {
+	return &Client{
+		HTTPClient:   PublicClient(15 * time.Second),
+		MaxBodySize:  5 * 1024 * 1024,
+		UserAgent:    DefaultUserAgent,
+		TTL:          5 * time.Minute,
+		MaxEntries:   256,
+		MaxCacheSize: 32 * 1024 * 1024,
+		cache:        make(map[string]*cacheEntry),
+	}
+}
+
+// Retrieve performs a HTTP Get request for the rss feed and decodes the results.
+func (c *Client) Retrieve(feed string) (*RSSDocument, error) {
+	data, charset, err := c.fetch(feed)
+	if err != nil {
+		return nil, err
+	}
+	return decodeRSS(withCharset(data, charset))
+}
+
+// RetrieveFeed performs a HTTP Get request for a feed of any supported format and decodes it into a Feed.
+func (c *Client) RetrieveFeed(feed string) (*Feed, error) {
+	data, charset, err := c.fetch(feed)
+	if err != nil {
+		return nil, err
+	}
+	return ParseFeed(withCharset(data, charset))
+}
+
+// Fetch performs a HTTP Get request for a feed and returns the body of the response, using the cache where it can.
+func (c *Client) Fetch(feed string) ([]byte, error) {
+	body, _, err := c.fetch(feed)
+	return body, err
+}
+
+// fetch is Fetch, also returning the character set named in the response's Content-Type header.
+func (c *Client) fetch(feed string) ([]byte, string, error) {
+	if feed == "" {
+		return nil, "", errors.New("No rss feed uri provided")
+	}
+	entry := c.cached(feed)
+	if entry != nil && time.Since(entry.fetched) < c.TTL {
+		return entry.body, entry.charset, nil
+	}
+
+	req, err := http.NewRequest("GET", feed, nil)
+	if err != nil {
+		return nil, "", err
+	}
+	req.Header.Set("User-Agent", c.UserAgent)
+	if entry != nil {
+		if entry.etag != "" {
+			req.Header.Set("If-None-Match", entry.etag)
+		}
+		if entry.lastModified != "" {
+			req.Header.Set("If-Modified-Since", entry.lastModified)
+		}
+	}
+
+	// Retrieve the feed document from the web.
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	// Close the response once we return from the function.
+	defer closeResponse(resp)
+
+	// An unchanged feed is served from the cache.
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		c.store(feed, &cacheEntry{
+			body:         entry.body,
+			etag:         entry.etag,
+			lastModified: entry.lastModified,
+			charset:      entry.charset,
+			fetched:      time.Now(),
+		})
+		return entry.body, entry.charset, nil
+	}
+
+	// Check the status code for a 200 so we know we have received a
+	// proper response.
+	if resp.StatusCode != 200 {
+		return nil, "", fmt.Errorf("HTTP Response Error %d\n", resp.StatusCode)
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, c.MaxBodySize+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if int64(len(body)) > c.MaxBodySize {
+		return nil, "", fmt.Errorf("Feed is larger than %d bytes", c.MaxBodySize)
+	}
+	var charset string
+	if _, params, err := mime.ParseMediaType(resp.Header.Get("Content-Type")); err == nil {
+		charset = params["charset"]
+	}
+	c.store(feed, &cacheEntry{
+		body:         body,
+		etag:         resp.Header.Get("ETag"),
+		lastModified: resp.Header.Get("Last-Modified"),
+		charset:      charset,
+		fetched:      time.Now(),
+	})
+	return body, charset, nil
+}
+
+// cached returns the cache entry for a URL, if there is one.
+func (c *Client) cached(feed string) *cacheEntry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cache[feed]
+}
+
+// store caches a response, making room by dropping the oldest entries when the cache is full. Nothing is kept when
+// caching is off, unless the response can be revalidated with a conditional request, or when the body is bigger than
+// the whole cache.
+func (c *Client) store(feed string, entry *cacheEntry) {
+	if c.TTL <= 0 && entry.etag == "" && entry.lastModified == "" {
+		return
+	}
+	size := int64(len(entry.body))
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.cache == nil {
+		c.cache = make(map[string]*cacheEntry)
+	}
+	if old, ok := c.cache[feed]; ok {
+		delete(c.cache, feed)
+		c.size -= int64(len(old.body))
+	}
+	if c.MaxCacheSize > 0 && size > c.MaxCacheSize {
+		return
+	}
+	for len(c.cache) > 0 && (c.MaxEntries > 0 && len(c.cache) >= c.MaxEntries ||
+		c.MaxCacheSize > 0 && c.size+size > c.MaxCacheSize) {
+		var oldest string
+		for k, v := range c.cache {
+			if oldest == "" || v.fetched.Before(c.cache[oldest].fetched) {
+				oldest = k
+			}
+		}
+		c.size -= int64(len(c.cache[oldest].body))
+		delete(c.cache, oldest)
+	}
+	c.cache[feed] = entry
+	c.size += size
+}
@@ -0,0 +1,145 @@
+package matchers
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeTransport answers requests with respond, recording each one.
+type fakeTransport struct {
+	requests []*http.Request
+	respond  func(req *http.Request) (int, http.Header, string)
+}
+
+func (f *fakeTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	f.requests = append(f.requests, req)
+	status, header, body := f.respond(req)
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		StatusCode: status,
+		Header:     header,
+		Body:       ioutil.NopCloser(strings.NewReader(body)),
+		Request:    req,
+	}, nil
+}
+
+// fakeClient creates a Client whose requests go to transport.
+func fakeClient(transport *fakeTransport) *Client {
+	c := NewClient()
+	c.HTTPClient = &http.Client{Transport: transport}
+	return c
+}
+
+// expire makes the cached response for a URL old enough to need checking again.
+func (c *Client) expire(feed string) {
+	c.cached(feed).fetched = time.Now().Add(-c.TTL - time.Second)
+}
+
+func TestFetchRevalidatesWithETag(t *testing.T) {
+	transport := &fakeTransport{respond: func(req *http.Request) (int, http.Header, string) {
+		if req.Header.Get("If-None-Match") == `"v1"` {
+			return http.StatusNotModified, nil, ""
+		}
+		return http.StatusOK, http.Header{"Etag": {`"v1"`}}, "first"
+	}}
+	c := fakeClient(transport)
+	for i := 0; i < 2; i++ {
+		if body, err := c.Fetch("http://example.com/feed"); err != nil || string(body) != "first" {
+			t.Fatalf("fetch %d = %q, %v", i, body, err)
+		}
+	}
+	if len(transport.requests) != 1 {
+		t.Fatalf("made %d requests within the TTL, want 1", len(transport.requests))
+	}
+	c.expire("http://example.com/feed")
+	if body, err := c.Fetch("http://example.com/feed"); err != nil || string(body) != "first" {
+		t.Fatalf("revalidated fetch = %q, %v", body, err)
+	}
+	if len(transport.requests) != 2 || transport.requests[1].Header.Get("If-None-Match") != `"v1"` {
+		t.Errorf("expired entry wasn't revalidated with its ETag")
+	}
+}
+
+func TestFetchRevalidatesWithLastModified(t *testing.T) {
+	modified := "Mon, 19 Oct 2026 10:00:00 GMT"
+	version := "first"
+	transport := &fakeTransport{respond: func(req *http.Request) (int, http.Header, string) {
+		if req.Header.Get("If-Modified-Since") == modified && version == "first" {
+			return http.StatusNotModified, nil, ""
+		}
+		return http.StatusOK, http.Header{"Last-Modified": {modified}}, version
+	}}
+	c := fakeClient(transport)
+	c.Fetch("http://example.com/feed")
+	c.expire("http://example.com/feed")
+	if body, _ := c.Fetch("http://example.com/feed"); string(body) != "first" {
+		t.Errorf("unchanged feed = %q, want the cached body", body)
+	}
+	if got := transport.requests[1].Header.Get("If-Modified-Since"); got != modified {
+		t.Errorf("If-Modified-Since = %q, want %q", got, modified)
+	}
+
+	// A changed feed replaces the cached body.
+	version = "second"
+	c.expire("http://example.com/feed")
+	if body, _ := c.Fetch("http://example.com/feed"); string(body) != "second" {
+		t.Errorf("changed feed = %q, want second", body)
+	}
+}
+
+func TestFetchWithoutCaching(t *testing.T) {
+	transport := &fakeTransport{respond: func(req *http.Request) (int, http.Header, string) {
+		return http.StatusOK, nil, "body"
+	}}
+	c := fakeClient(transport)
+	c.TTL = 0
+	c.Fetch("http://example.com/feed")
+	c.Fetch("http://example.com/feed")
+	if len(transport.requests) != 2 {
+		t.Errorf("made %d requests with caching off, want 2", len(transport.requests))
+	}
+}
+
+func TestCacheLimits(t *testing.T) {
+	transport := &fakeTransport{respond: func(req *http.Request) (int, http.Header, string) {
+		return http.StatusOK, nil, strings.Repeat("x", len(req.URL.Path))
+	}}
+	c := fakeClient(transport)
+	c.MaxEntries = 3
+	c.MaxCacheSize = 10
+	c.Fetch("http://example.com/aaaa")                       // 5 bytes
+	c.Fetch("http://example.com/bbbb")                       // 5 bytes
+	c.Fetch("http://example.com/cccc")                       // 5 bytes, pushing out the first
+	c.Fetch("http://example.com/" + strings.Repeat("d", 20)) // bigger than the whole cache
+	if c.cached("http://example.com/aaaa") != nil {
+		t.Errorf("oldest entry kept past the size cap")
+	}
+	if c.cached("http://example.com/bbbb") == nil || c.cached("http://example.com/cccc") == nil {
+		t.Errorf("newer entries dropped")
+	}
+	if c.cached("http://example.com/"+strings.Repeat("d", 20)) != nil {
+		t.Errorf("body bigger than the cache was cached")
+	}
+	if c.size != 10 {
+		t.Errorf("cache size = %d, want 10", c.size)
+	}
+}
+
+func TestRetrieveFeedRefusesPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback feed was requested")
+	}))
+	defer server.Close()
+	if _, err := NewClient().RetrieveFeed(server.URL); err == nil {
+		t.Errorf("RetrieveFeed(%s) succeeded, want an error", server.URL)
+	}
+	if _, err := RetrieveCalendar(server.URL); err == nil {
+		t.Errorf("RetrieveCalendar(%s) succeeded, want an error", server.URL)
+	}
+}
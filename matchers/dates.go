@@ -0,0 +1,142 @@
+package matchers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// timeLayouts are the date formats accepted in feeds, in the order they're tried. RSS asks for RFC 822 dates and Atom
+// for RFC 3339, but real feeds drop the weekday, use single-digit days, two-digit years, leave out the seconds or the
+// zone, or just use whatever their framework prints by default.
+var timeLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"Mon, 2 January 2006 15:04:05 -0700",
+	"Mon, 2 January 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -07:00",
+	"Mon, 2 Jan 2006",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05 MST",
+	"2 January 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	// RFC 850, once its full weekday name has been shortened
+	"Mon, 02-Jan-06 15:04:05 -0700",
+	"Mon, 02-Jan-06 15:04:05 MST",
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05",
+	"2006-01-02",
+	time.ANSIC,
+	time.UnixDate,
+	time.RubyDate,
+	"Mon Jan 2 2006 15:04:05 GMT-0700",
+	"January 2, 2006 15:04:05 MST",
+	"January 2, 2006",
+}
+
+// zoneOffsets maps the zone abbreviations that show up in feeds to their offsets. time.Parse only knows the offset of
+// an abbreviation when it's used by the local zone, and otherwise quietly treats it as UTC.
+var zoneOffsets = map[string]string{
+	"UT":   "+0000",
+	"UTC":  "+0000",
+	"GMT":  "+0000",
+	"Z":    "+0000",
+	"EST":  "-0500",
+	"EDT":  "-0400",
+	"CST":  "-0600",
+	"CDT":  "-0500",
+	"MST":  "-0700",
+	"MDT":  "-0600",
+	"PST":  "-0800",
+	"PDT":  "-0700",
+	"AKST": "-0900",
+	"AKDT": "-0800",
+	"HST":  "-1000",
+	"BST":  "+0100",
+	"CET":  "+0100",
+	"CEST": "+0200",
+	"EET":  "+0200",
+	"EEST": "+0300",
+	"IST":  "+0530",
+	"JST":  "+0900",
+	"AEST": "+1000",
+	"AEDT": "+1100",
+}
+
+// ParseTime parses a feed date in any of the formats feeds commonly use. Dates without a zone are taken as UTC.
+func ParseTime(value string) (time.Time, error) {
+	raw := normalizeTime(value)
+	if raw == "" {
+		return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+	}
+	for _, layout := range timeLayouts {
+		t, err := time.Parse(layout, raw)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date %q", value)
+}
+
+// normalizeTime tidies up a feed date before parsing: extra whitespace is collapsed, trailing comments like
+// "(Eastern Standard Time)" are dropped, a missing comma after the weekday is added, and known zone abbreviations
+// are swapped for numeric offsets.
+func normalizeTime(value string) string {
+	raw := strings.TrimSpace(value)
+	if i := strings.Index(raw, " ("); i > 0 && strings.HasSuffix(raw, ")") {
+		raw = raw[:i]
+	}
+	fields := strings.Fields(raw)
+	if len(fields) < 1 {
+		return ""
+	}
+	// "Mon 02 Jan 2006 ..." and "Mon., 02 Jan ..." are both seen in the wild.
+	if w := strings.TrimRight(fields[0], ".,"); len(fields) > 2 && len(w) >= 3 && isWeekday(w) && isDigit(fields[1][0]) {
+		fields[0] = w[:3] + ","
+	}
+	last := len(fields) - 1
+	if offset, ok := zoneOffsets[strings.ToUpper(fields[last])]; ok && last > 0 {
+		fields[last] = offset
+	}
+	return strings.Join(fields, " ")
+}
+
+// isDigit reports whether b is an ASCII digit.
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+// isWeekday reports whether word is a weekday name or abbreviation.
+func isWeekday(word string) bool {
+	w := strings.ToLower(word)
+	for _, day := range []string{"monday", "tuesday", "wednesday", "thursday", "friday", "saturday", "sunday"} {
+		if w == day || (len(w) >= 3 && strings.HasPrefix(day, w)) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseTimeOrZero parses a feed date, leaving it zero when it can't be understood.
+func parseTimeOrZero(value string) time.Time {
+	t, _ := ParseTime(value)
+	return t
+}
@@ -0,0 +1,31 @@
+package matchers
+
+import (
+	"testing"
+	"time"
+)
+
+// FuzzParseDate checks that no date string makes ParseTime panic, and that the dates it rejects come back zero.
+func FuzzParseDate(f *testing.F) {
+	for _, seed := range []string{
+		"Mon, 19 Oct 2026 10:00:00 EST",
+		"Mon 19 Oct 2026 10:00:00 -0500",
+		"19 Oct 26 10:00 GMT",
+		"2026-10-19T10:00:00Z",
+		"2026-10-19",
+		"Mon Oct 19 2026 10:00:00 GMT-0500 (Eastern Standard Time)",
+		"Mon., 19 Oct",
+		" (",
+		"Tuesday",
+		"2026-13-45T99:99:99Z",
+		"",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, value string) {
+		parsed, err := ParseTime(value)
+		if err != nil && !parsed.Equal(time.Time{}) {
+			t.Errorf("ParseTime(%q) = %v with error %v, want the zero time", value, parsed, err)
+		}
+	})
+}
@@ -0,0 +1,182 @@
+/*
+Package matchers retrieves and decodes the documents the bots read from the web. Retrieve decodes RSS 2.0 documents
+into their RSS-specific structs, while RetrieveFeed works out whether a feed is RSS 2.0, RSS 1.0/RDF, Atom 1.0 or JSON
+Feed 1.1 and normalizes it into a Feed. RetrieveCalendar decodes the VEVENTs of an iCalendar (.ics) document.
+*/
+package matchers
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Feed is a feed document of any supported format (RSS 2.0, RSS 1.0/RDF, Atom 1.0 or JSON Feed 1.1) normalized
+	// into one model.
+	Feed struct {
+		// Format names the format the feed was parsed from, e.g. "rss", "rdf", "atom" or "json"
+		Format      string
+		Title       string
+		Link        string
+		Description string
+		Updated     time.Time
+		Items       []FeedItem
+	}
+
+	// FeedItem is a single story, post or episode from a Feed.
+	FeedItem struct {
+		// ID is the item's GUID or ID, falling back to its link when the feed doesn't give one
+		ID          string
+		Title       string
+		Link        string
+		Description string
+		Content     string
+		Published   time.Time
+		Updated     time.Time
+		Authors     []string
+		Categories  []string
+		Enclosures  []Enclosure
+		// Image is a thumbnail or picture for the item, when the feed gives one
+		Image string
+	}
+
+	// Enclosure is a file attached to a FeedItem, like a podcast episode's audio.
+	Enclosure struct {
+		URL    string
+		Type   string
+		Length int64
+	}
+)
+
+// Feed formats, as reported in Feed.Format.
+const (
+	FormatRSS  = "rss"
+	FormatRDF  = "rdf"
+	FormatAtom = "atom"
+	FormatJSON = "json"
+)
+
+// RetrieveFeed performs a HTTP Get request for a feed of any supported format and decodes it into a Feed, using
+// DefaultClient.
+func RetrieveFeed(feed string) (*Feed, error) {
+	return DefaultClient.RetrieveFeed(feed)
+}
+
+// ParseFeed works out which format a feed document is in and decodes it into a Feed.
+func ParseFeed(data []byte) (*Feed, error) {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	trimmed := bytes.TrimSpace(data)
+	if len(trimmed) < 1 {
+		return nil, errors.New("Empty feed document")
+	}
+	if trimmed[0] == '{' {
+		return parseJSONFeed(trimmed)
+	}
+	root, err := rootElement(trimmed)
+	if err != nil {
+		return nil, err
+	}
+	switch strings.ToLower(root.Local) {
+	case "rss":
+		document, err := decodeRSS(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return document.Feed(), nil
+	case "rdf":
+		return parseRDF(trimmed)
+	case "feed":
+		return parseAtom(trimmed)
+	}
+	return nil, fmt.Errorf("Unrecognized feed format <%s>", root.Local)
+}
+
+// rootElement returns the name of an XML document's first element.
+func rootElement(data []byte) (xml.Name, error) {
+	decoder := newDecoder(data)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return xml.Name{}, fmt.Errorf("Couldn't find the feed's root element: %v", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name, nil
+		}
+	}
+}
+
+// Feed normalizes an RSS 2.0 document.
+func (document *RSSDocument) Feed() *Feed {
+	ch := document.Channel
+	feed := &Feed{
+		Format:      FormatRSS,
+		Title:       strings.TrimSpace(ch.Title),
+		Link:        strings.TrimSpace(ch.Link),
+		Description: strings.TrimSpace(ch.Description),
+		Updated:     parseTimeOrZero(ch.LastBuildDate),
+		Items:       make([]FeedItem, 0, len(ch.Item)),
+	}
+	if feed.Updated.IsZero() {
+		feed.Updated = parseTimeOrZero(ch.PubDate)
+	}
+	for _, item := range ch.Item {
+		feed.Items = append(feed.Items, item.FeedItem())
+	}
+	return feed
+}
+
+// FeedItem normalizes an RSS 2.0 item.
+func (item Item) FeedItem() FeedItem {
+	fi := FeedItem{
+		ID:          strings.TrimSpace(item.GUID),
+		Title:       strings.TrimSpace(item.Title),
+		Link:        strings.TrimSpace(item.Link),
+		Description: strings.TrimSpace(item.Description),
+		Content:     strings.TrimSpace(item.ContentEncoded),
+		Published:   parseTimeOrZero(item.PubDate),
+		Categories:  trimAll(item.Categories),
+		Image:       strings.TrimSpace(item.Thumbnail),
+	}
+	if fi.ID == "" {
+		fi.ID = fi.Link
+	}
+	if fi.Published.IsZero() {
+		fi.Published = parseTimeOrZero(item.DCDate)
+	}
+	if a := strings.TrimSpace(item.Author); a != "" {
+		fi.Authors = []string{a}
+	}
+	if c := strings.TrimSpace(item.Creator); c != "" && len(fi.Authors) < 1 {
+		fi.Authors = []string{c}
+	}
+	for _, e := range item.Enclosures {
+		length, _ := strconv.ParseInt(strings.TrimSpace(e.Length), 10, 64)
+		fi.Enclosures = append(fi.Enclosures, Enclosure{URL: e.URL, Type: e.Type, Length: length})
+	}
+	for _, m := range item.Media {
+		if m.URL == "" {
+			continue
+		}
+		fi.Enclosures = append(fi.Enclosures, Enclosure{URL: m.URL, Type: m.Type})
+		if fi.Image == "" && (m.Medium == "image" || strings.HasPrefix(m.Type, "image/")) {
+			fi.Image = m.URL
+		}
+	}
+	return fi
+}
+
+// trimAll trims the space around each value and drops the empty ones.
+func trimAll(values []string) []string {
+	var trimmed []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			trimmed = append(trimmed, v)
+		}
+	}
+	return trimmed
+}
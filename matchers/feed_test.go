@@ -0,0 +1,147 @@
+package matchers
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// FuzzParseFeed checks that no document, however broken, makes ParseFeed panic, and that a feed is always returned
+// when there's no error.
+func FuzzParseFeed(f *testing.F) {
+	for _, seed := range []string{
+		`<?xml version="1.0"?><rss version="2.0"><channel><title>News</title><item><title>One</title>` +
+			`<link>http://example.com/1</link><pubDate>Mon, 19 Oct 2026 10:00:00 EST</pubDate></item></channel></rss>`,
+		`<rss><channel><item><title>Unclosed`,
+		`<rss><channel><item><title>&nbsp;&bogus;</title><description><![CDATA[<p>hi`,
+		`<?xml version="1.0" encoding="iso-8859-1"?><rss><channel><title>Caf` + "\xe9\x93" + `</title></channel></rss>`,
+		`<?xml version="1.0" encoding="ebcdic"?><rss/>`,
+		`<feed xmlns="http://www.w3.org/2005/Atom"><title>Atom</title><entry><title>One</title>` +
+			`<link href="http://example.com/1"/><updated>2026-10-19T10:00:00Z</updated></entry></feed>`,
+		`<feed><entry><link rel="alternate"><updated>not a date</updated></entry>`,
+		`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/">` +
+			`<channel><title>RDF</title></channel><item><title>One</title><link>http://example.com/1</link></item></rdf:RDF>`,
+		`<rdf:RDF><item><dc:date>2026-13-45</dc:date>`,
+		`{"version":"https://jsonfeed.org/version/1.1","items":[{"id":1}]}`,
+		`{"items":`,
+		"\xef\xbb\xbf   ",
+		`<html><body>Not a feed</body></html>`,
+	} {
+		f.Add([]byte(seed))
+	}
+	f.Fuzz(func(t *testing.T, data []byte) {
+		feed, err := ParseFeed(data)
+		if err == nil && feed == nil {
+			t.Errorf("ParseFeed(%q) returned neither a feed nor an error", data)
+		}
+	})
+}
+
+func TestParseFeedFormats(t *testing.T) {
+	published := time.Date(2026, 10, 19, 10, 0, 0, 0, time.UTC)
+	for _, test := range []struct {
+		name, data string
+		format     string
+		want       FeedItem
+	}{
+		{
+			"atom",
+			`<feed xmlns="http://www.w3.org/2005/Atom" xmlns:media="http://search.yahoo.com/mrss/"><title>Atom</title>` +
+				`<author><name>Ann</name></author><entry><id>urn:1</id><title>One</title>` +
+				`<media:title>Thumbnail caption</media:title><link href="http://example.com/1"/>` +
+				`<link rel="enclosure" href="http://example.com/1.mp3" type="audio/mpeg" length="1024"/>` +
+				`<published>2026-10-19T10:00:00Z</published><category term="jazz"/>` +
+				`<summary type="html">&lt;b&gt;Bold&lt;/b&gt; claim</summary>` +
+				`<content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>there</b></p></div></content>` +
+				`</entry></feed>`,
+			FormatAtom,
+			FeedItem{
+				ID:          "urn:1",
+				Title:       "One",
+				Link:        "http://example.com/1",
+				Description: "<b>Bold</b> claim",
+				Content:     "<p>Hello <b>there</b></p>",
+				Published:   published,
+				Authors:     []string{"Ann"},
+				Categories:  []string{"jazz"},
+				Enclosures:  []Enclosure{{URL: "http://example.com/1.mp3", Type: "audio/mpeg", Length: 1024}},
+			},
+		},
+		{
+			"atom without a namespace or ID",
+			`<feed><entry><title type="text">Plain &amp; simple</title><link rel="alternate" href="http://example.com/2"/>` +
+				`<updated>2026-10-19T10:00:00Z</updated><content>Text</content></entry></feed>`,
+			FormatAtom,
+			FeedItem{
+				ID:        "http://example.com/2",
+				Title:     "Plain & simple",
+				Link:      "http://example.com/2",
+				Content:   "Text",
+				Published: published,
+				Updated:   published,
+			},
+		},
+		{
+			"rdf",
+			`<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" ` +
+				`xmlns:dc="http://purl.org/dc/elements/1.1/"><channel><title>RDF</title></channel>` +
+				`<item rdf:about="http://example.com/3"><title>Three</title><link>http://example.com/3</link>` +
+				`<description>About three</description><dc:date>2026-10-19T10:00:00Z</dc:date>` +
+				`<dc:creator>Bo</dc:creator><dc:subject>news</dc:subject></item></rdf:RDF>`,
+			FormatRDF,
+			FeedItem{
+				ID:          "http://example.com/3",
+				Title:       "Three",
+				Link:        "http://example.com/3",
+				Description: "About three",
+				Published:   published,
+				Authors:     []string{"Bo"},
+				Categories:  []string{"news"},
+			},
+		},
+		{
+			"json feed",
+			`{"version":"https://jsonfeed.org/version/1.1","title":"JSON","authors":[{"name":"Cy"}],"items":[` +
+				`{"id":4,"external_url":"http://example.com/4","title":"Four","content_text":"Four's text",` +
+				`"date_published":"2026-10-19T10:00:00Z","tags":["music"],` +
+				`"attachments":[{"url":"http://example.com/4.mp3","mime_type":"audio/mpeg","size_in_bytes":2048}]}]}`,
+			FormatJSON,
+			FeedItem{
+				ID:         "4",
+				Title:      "Four",
+				Link:       "http://example.com/4",
+				Content:    "Four's text",
+				Published:  published,
+				Authors:    []string{"Cy"},
+				Categories: []string{"music"},
+				Enclosures: []Enclosure{{URL: "http://example.com/4.mp3", Type: "audio/mpeg", Length: 2048}},
+			},
+		},
+		{
+			"json feed 1.0 author",
+			`{"version":"https://jsonfeed.org/version/1","author":{"name":"Di"},"items":[{"id":"five",` +
+				`"url":"http://example.com/5","content_html":"<p>Five</p>","content_text":"Five"}]}`,
+			FormatJSON,
+			FeedItem{ID: "five", Link: "http://example.com/5", Content: "<p>Five</p>", Authors: []string{"Di"}},
+		},
+	} {
+		feed, err := ParseFeed([]byte(test.data))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if feed.Format != test.format || len(feed.Items) != 1 {
+			t.Errorf("%s: format %q with %d items, want %q with 1", test.name, feed.Format, len(feed.Items), test.format)
+			continue
+		}
+		got := feed.Items[0]
+		if !got.Published.Equal(test.want.Published) || !got.Updated.Equal(test.want.Updated) {
+			t.Errorf("%s: published, updated = %v, %v, want %v, %v", test.name, got.Published, got.Updated,
+				test.want.Published, test.want.Updated)
+		}
+		got.Published, got.Updated = test.want.Published, test.want.Updated
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: item = %+v\nwant %+v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,332 @@
+package matchers
+
+import (
+	"bytes"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Calendar is an iCalendar (RFC 5545) document, like the .ics calendars venues publish.
+	Calendar struct {
+		Name   string
+		Events []CalendarEvent
+	}
+
+	// CalendarEvent is a VEVENT from a calendar.
+	CalendarEvent struct {
+		UID         string
+		Summary     string
+		Description string
+		Location    string
+		URL         string
+		Categories  []string
+		// Start and End are in the zone given by the event's TZID, or UTC
+		Start time.Time
+		End   time.Time
+		// AllDay is set for events given as dates rather than times. They start at midnight UTC on their first day,
+		// and without a DTEND last the whole day.
+		AllDay bool
+		// Floating is set for times given without a zone, which happen at the same wall-clock time wherever you are.
+		// They're read as UTC.
+		Floating bool
+		// Created is when the event was added to the calendar, if it says
+		Created time.Time
+		// Recurrence is the event's RRULE, if it repeats
+		Recurrence *Recurrence
+		// Exceptions are the EXDATEs, the starts of occurrences that have been dropped
+		Exceptions []time.Time
+		// RecurrenceID is set when this event replaces one occurrence of a repeating event with the same UID. It's the
+		// start the occurrence would have had.
+		RecurrenceID time.Time
+		// Status is e.g. "CONFIRMED", "TENTATIVE" or "CANCELLED"
+		Status string
+	}
+
+	// icsProperty is one content line of a calendar, like "DTSTART;TZID=America/New_York:20261020T190000".
+	icsProperty struct {
+		Name   string
+		Params map[string]string
+		Value  string
+	}
+)
+
+// RetrieveCalendar performs a HTTP Get request for an iCalendar document and decodes it.
+func RetrieveCalendar(link string) (*Calendar, error) {
+	return DefaultClient.RetrieveCalendar(link)
+}
+
+// RetrieveCalendar performs a HTTP Get request for an iCalendar document and decodes it.
+func (c *Client) RetrieveCalendar(link string) (*Calendar, error) {
+	data, err := c.Fetch(link)
+	if err != nil {
+		return nil, err
+	}
+	return ParseICS(data)
+}
+
+// ParseICS decodes an iCalendar document. Only its VEVENTs are kept; events without a start time are dropped. Use
+// Between to expand repeating events into their occurrences.
+func ParseICS(data []byte) (*Calendar, error) {
+	props := icsProperties(data)
+	if len(props) == 0 || props[0].Name != "BEGIN" || !strings.EqualFold(props[0].Value, "VCALENDAR") {
+		return nil, errors.New("Document is not an iCalendar")
+	}
+	var cal Calendar
+	var event *CalendarEvent
+	var rrule string
+	depth := 0
+	for _, prop := range props[1:] {
+		switch prop.Name {
+		case "BEGIN":
+			depth++
+			if depth == 1 && strings.EqualFold(prop.Value, "VEVENT") {
+				event = &CalendarEvent{}
+				rrule = ""
+			}
+			continue
+		case "END":
+			if depth == 1 && event != nil {
+				if rrule != "" {
+					// UNTIL is read in the zone DTSTART was given in, which is only known once the event has ended.
+					event.Recurrence = parseRecurrence(rrule, event.Start.Location())
+				}
+				if !event.Start.IsZero() {
+					cal.Events = append(cal.Events, *event)
+				}
+				event = nil
+			}
+			depth--
+			continue
+		}
+		if depth == 0 && prop.Name == "X-WR-CALNAME" {
+			cal.Name = icsText(prop.Value)
+		}
+		if depth != 1 || event == nil {
+			// Alarms and other components nested in an event are skipped.
+			continue
+		}
+		switch prop.Name {
+		case "UID":
+			event.UID = prop.Value
+		case "SUMMARY":
+			event.Summary = icsText(prop.Value)
+		case "DESCRIPTION":
+			event.Description = icsText(prop.Value)
+		case "LOCATION":
+			event.Location = icsText(prop.Value)
+		case "URL":
+			event.URL = prop.Value
+		case "CATEGORIES":
+			for _, category := range strings.Split(prop.Value, ",") {
+				if category = strings.TrimSpace(icsText(category)); category != "" {
+					event.Categories = append(event.Categories, category)
+				}
+			}
+		case "DTSTART":
+			event.Start, event.AllDay, event.Floating = icsTime(prop)
+		case "DTEND":
+			event.End, _, _ = icsTime(prop)
+		case "CREATED":
+			// DTSTAMP isn't used instead, since many calendars stamp every event with the time they were generated.
+			event.Created, _, _ = icsTime(prop)
+		case "RRULE":
+			rrule = prop.Value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.Value, ",") {
+				prop.Value = value
+				if t, _, _ := icsTime(prop); !t.IsZero() {
+					event.Exceptions = append(event.Exceptions, t)
+				}
+			}
+		case "RECURRENCE-ID":
+			event.RecurrenceID, _, _ = icsTime(prop)
+		case "STATUS":
+			event.Status = strings.ToUpper(prop.Value)
+		}
+	}
+	return &cal, nil
+}
+
+// icsProperties splits a calendar into its content lines, unfolding lines that were wrapped onto the next with a
+// leading space or tab.
+func icsProperties(data []byte) []icsProperty {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	var lines []string
+	for _, line := range strings.Split(strings.Replace(string(data), "\r\n", "\n", -1), "\n") {
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, "\r"))
+	}
+	props := make([]icsProperty, 0, len(lines))
+	for _, line := range lines {
+		if prop, ok := parseICSLine(line); ok {
+			props = append(props, prop)
+		}
+	}
+	return props
+}
+
+// parseICSLine splits a content line into its name, parameters and value. Colons and semicolons inside quoted
+// parameter values don't count.
+func parseICSLine(line string) (icsProperty, bool) {
+	prop := icsProperty{Params: make(map[string]string)}
+	quoted := false
+	colon := -1
+	for i := 0; i < len(line) && colon < 0; i++ {
+		switch line[i] {
+		case '"':
+			quoted = !quoted
+		case ':':
+			if !quoted {
+				colon = i
+			}
+		}
+	}
+	if colon < 1 {
+		return prop, false
+	}
+	prop.Value = line[colon+1:]
+	parts := strings.Split(line[:colon], ";")
+	prop.Name = strings.ToUpper(strings.TrimSpace(parts[0]))
+	for _, part := range parts[1:] {
+		if eq := strings.IndexByte(part, '='); eq > 0 {
+			prop.Params[strings.ToUpper(part[:eq])] = strings.Trim(part[eq+1:], "\"")
+		}
+	}
+	return prop, prop.Name != ""
+}
+
+// icsText unescapes a TEXT value.
+func icsText(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
+
+// windowsZones maps the Windows time zone names some calendars use as TZIDs to their IANA names.
+var windowsZones = map[string]string{
+	"Eastern Standard Time":     "America/New_York",
+	"Central Standard Time":     "America/Chicago",
+	"Mountain Standard Time":    "America/Denver",
+	"US Mountain Standard Time": "America/Phoenix",
+	"Pacific Standard Time":     "America/Los_Angeles",
+	"Alaskan Standard Time":     "America/Anchorage",
+	"Hawaiian Standard Time":    "Pacific/Honolulu",
+	"GMT Standard Time":         "Europe/London",
+	"W. Europe Standard Time":   "Europe/Berlin",
+	"Romance Standard Time":     "Europe/Paris",
+	"UTC":                       "UTC",
+}
+
+// icsZone finds the zone named by a TZID. Besides IANA names, it understands Windows names and prefixed names like
+// "/mozilla.org/20050126_1/America/New_York". Zones it can't find are UTC.
+func icsZone(tzid string) *time.Location {
+	tzid = strings.Trim(tzid, "\" ")
+	if name, ok := windowsZones[tzid]; ok {
+		tzid = name
+	}
+	for {
+		if loc, err := time.LoadLocation(tzid); err == nil && tzid != "" && tzid != "Local" {
+			return loc
+		}
+		slash := strings.IndexByte(tzid, '/')
+		if slash < 0 {
+			return time.UTC
+		}
+		tzid = tzid[slash+1:]
+	}
+}
+
+// icsTime parses a DATE or DATE-TIME value, reporting whether it was a date and whether it was floating. Times are
+// read in the zone named by the TZID parameter, if any. Values that can't be parsed are zero.
+func icsTime(prop icsProperty) (time.Time, bool, bool) {
+	loc := time.UTC
+	if tzid, ok := prop.Params["TZID"]; ok {
+		loc = icsZone(tzid)
+	}
+	t, allDay, floating := icsDate(prop.Value, loc)
+	if _, ok := prop.Params["TZID"]; ok {
+		floating = false
+	}
+	return t, allDay, floating
+}
+
+// icsDate parses a date ("20261020"), a UTC time ("20261020T230000Z") or a local time ("20261020T190000"), which is
+// read in loc. It reports whether the value was a date and whether it was a local time.
+func icsDate(value string, loc *time.Location) (time.Time, bool, bool) {
+	value = strings.TrimSpace(value)
+	if t, err := time.Parse("20060102T150405Z", value); err == nil {
+		return t, false, false
+	}
+	if t, err := time.ParseInLocation("20060102T150405", value, loc); err == nil {
+		return t, false, true
+	}
+	if t, err := time.Parse("20060102", value); err == nil {
+		return t, true, false
+	}
+	return time.Time{}, false, false
+}
+
+// Between returns the occurrences of the calendar's events that start at or after from and before to, in order.
+// Repeating events are expanded, skipping their EXDATEs and replacing occurrences that have been moved or changed.
+// Cancelled events and occurrences are left out.
+func (cal *Calendar) Between(from, to time.Time) []CalendarEvent {
+	// Overrides are keyed by UID and the start the occurrence would have had.
+	overrides := make(map[string]CalendarEvent)
+	for _, event := range cal.Events {
+		if !event.RecurrenceID.IsZero() {
+			overrides[event.UID+"\x00"+event.RecurrenceID.UTC().Format(time.RFC3339)] = event
+		}
+	}
+	var occurrences []CalendarEvent
+	for _, event := range cal.Events {
+		if !event.RecurrenceID.IsZero() {
+			continue
+		}
+		starts := []time.Time{event.Start}
+		if event.Recurrence != nil {
+			starts = event.Recurrence.starts(event.Start, to)
+		}
+		duration := event.End.Sub(event.Start)
+		if event.End.IsZero() {
+			duration = 0
+			if event.AllDay {
+				duration = 24 * time.Hour
+			}
+		}
+		for _, start := range starts {
+			if excluded(start, event.Exceptions) {
+				continue
+			}
+			occurrence := event
+			if override, ok := overrides[event.UID+"\x00"+start.UTC().Format(time.RFC3339)]; ok {
+				occurrence = override
+				if occurrence.End.IsZero() {
+					occurrence.End = occurrence.Start.Add(duration)
+				}
+			} else {
+				occurrence.Start = start
+				occurrence.End = start.Add(duration)
+			}
+			if occurrence.Status == "CANCELLED" || occurrence.Start.Before(from) || !occurrence.Start.Before(to) {
+				continue
+			}
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	sort.SliceStable(occurrences, func(i, j int) bool { return occurrences[i].Start.Before(occurrences[j].Start) })
+	return occurrences
+}
+
+// excluded reports whether t is one of the given times.
+func excluded(t time.Time, exceptions []time.Time) bool {
+	for _, e := range exceptions {
+		if t.Equal(e) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,135 @@
+package matchers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type (
+	// jsonAuthor defines the fields associated with an author
+	// in the json feed document.
+	jsonAuthor struct {
+		Name string `json:"name"`
+		URL  string `json:"url"`
+	}
+
+	// jsonAttachment defines the fields associated with an attachment
+	// in the json feed document.
+	jsonAttachment struct {
+		URL         string `json:"url"`
+		MimeType    string `json:"mime_type"`
+		SizeInBytes int64  `json:"size_in_bytes"`
+	}
+
+	// jsonItem defines the fields associated with an item
+	// in the json feed document.
+	jsonItem struct {
+		ID            json.RawMessage  `json:"id"`
+		URL           string           `json:"url"`
+		ExternalURL   string           `json:"external_url"`
+		Title         string           `json:"title"`
+		ContentHTML   string           `json:"content_html"`
+		ContentText   string           `json:"content_text"`
+		Summary       string           `json:"summary"`
+		DatePublished string           `json:"date_published"`
+		DateModified  string           `json:"date_modified"`
+		Authors       []jsonAuthor     `json:"authors"`
+		Author        *jsonAuthor      `json:"author"`
+		Tags          []string         `json:"tags"`
+		Attachments   []jsonAttachment `json:"attachments"`
+	}
+
+	// jsonDocument defines the fields associated with the json feed document.
+	jsonDocument struct {
+		Version     string       `json:"version"`
+		Title       string       `json:"title"`
+		HomePageURL string       `json:"home_page_url"`
+		Description string       `json:"description"`
+		Authors     []jsonAuthor `json:"authors"`
+		Author      *jsonAuthor  `json:"author"`
+		Items       []jsonItem   `json:"items"`
+	}
+)
+
+// parseJSONFeed decodes a JSON Feed document into a Feed. Version 1.0's single "author" is read as well as 1.1's
+// "authors".
+func parseJSONFeed(data []byte) (*Feed, error) {
+	var document jsonDocument
+	err := json.Unmarshal(data, &document)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasPrefix(document.Version, "https://jsonfeed.org/version/") {
+		return nil, fmt.Errorf("Unrecognized JSON feed version %q", document.Version)
+	}
+	feed := &Feed{
+		Format:      FormatJSON,
+		Title:       strings.TrimSpace(document.Title),
+		Link:        strings.TrimSpace(document.HomePageURL),
+		Description: strings.TrimSpace(document.Description),
+		Items:       make([]FeedItem, 0, len(document.Items)),
+	}
+	feedAuthors := jsonAuthorNames(document.Authors, document.Author)
+	for _, item := range document.Items {
+		fi := FeedItem{
+			ID:          jsonID(item.ID),
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.URL),
+			Description: strings.TrimSpace(item.Summary),
+			Content:     strings.TrimSpace(item.ContentHTML),
+			Published:   parseTimeOrZero(item.DatePublished),
+			Updated:     parseTimeOrZero(item.DateModified),
+			Authors:     jsonAuthorNames(item.Authors, item.Author),
+			Categories:  trimAll(item.Tags),
+		}
+		if fi.Link == "" {
+			fi.Link = strings.TrimSpace(item.ExternalURL)
+		}
+		if fi.ID == "" {
+			fi.ID = fi.Link
+		}
+		if fi.Content == "" {
+			fi.Content = strings.TrimSpace(item.ContentText)
+		}
+		if len(fi.Authors) < 1 {
+			fi.Authors = feedAuthors
+		}
+		for _, a := range item.Attachments {
+			fi.Enclosures = append(fi.Enclosures, Enclosure{URL: a.URL, Type: a.MimeType, Length: a.SizeInBytes})
+		}
+		if fi.Updated.After(feed.Updated) {
+			feed.Updated = fi.Updated
+		}
+		if fi.Published.After(feed.Updated) {
+			feed.Updated = fi.Published
+		}
+		feed.Items = append(feed.Items, fi)
+	}
+	return feed, nil
+}
+
+// jsonAuthorNames collects the names from a JSON Feed 1.1 author list and a JSON Feed 1.0 single author.
+func jsonAuthorNames(authors []jsonAuthor, author *jsonAuthor) []string {
+	var names []string
+	for _, a := range authors {
+		if name := strings.TrimSpace(a.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	if author != nil && len(names) < 1 {
+		if name := strings.TrimSpace(author.Name); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// jsonID reads an item ID. The spec says IDs are strings, but some feeds publish them as numbers.
+func jsonID(raw json.RawMessage) string {
+	var id string
+	if err := json.Unmarshal(raw, &id); err == nil {
+		return strings.TrimSpace(id)
+	}
+	return strings.TrimSpace(string(raw))
+}
@@ -0,0 +1,127 @@
+package matchers
+
+import (
+	"encoding/xml"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+)
+
+type (
+	// Outline defines the fields associated with the outline tag
+	// in the opml document. Outlines with an xmlUrl are feeds; outlines without one are folders of other outlines.
+	Outline struct {
+		Text     string    `xml:"text,attr"`
+		Title    string    `xml:"title,attr,omitempty"`
+		Type     string    `xml:"type,attr,omitempty"`
+		XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+		HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+		Category string    `xml:"category,attr,omitempty"`
+		Outlines []Outline `xml:"outline"`
+	}
+
+	// opmlHead defines the fields associated with the head tag
+	// in the opml document.
+	opmlHead struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	}
+
+	// opmlBody defines the fields associated with the body tag
+	// in the opml document.
+	opmlBody struct {
+		Outlines []Outline `xml:"outline"`
+	}
+
+	// OPMLDocument defines the fields associated with the opml document.
+	OPMLDocument struct {
+		XMLName xml.Name `xml:"opml"`
+		Version string   `xml:"version,attr"`
+		Head    opmlHead `xml:"head"`
+		Body    opmlBody `xml:"body"`
+	}
+
+	// Subscription is a feed listed in an OPML document, with the folder it was filed under as its category.
+	Subscription struct {
+		Title    string
+		URL      string
+		HTMLURL  string
+		Category string
+	}
+)
+
+// ParseOPML decodes an OPML document into the list of feeds it holds. A feed's category comes from its category
+// attribute if it has one, and otherwise from the names of the folders it's nested in, joined with "/".
+func ParseOPML(data []byte) ([]Subscription, error) {
+	var document OPMLDocument
+	err := newDecoder(data).Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	subs := make([]Subscription, 0)
+	subs = flattenOutlines(subs, document.Body.Outlines, nil)
+	if len(subs) < 1 {
+		return nil, errors.New("No feeds found in the OPML document")
+	}
+	return subs, nil
+}
+
+// flattenOutlines collects the feeds from a list of outlines and everything nested in them.
+func flattenOutlines(subs []Subscription, outlines []Outline, folders []string) []Subscription {
+	for _, o := range outlines {
+		title := strings.TrimSpace(o.Title)
+		if title == "" {
+			title = strings.TrimSpace(o.Text)
+		}
+		if o.XMLURL == "" {
+			subs = flattenOutlines(subs, o.Outlines, append(folders, title))
+			continue
+		}
+		category := strings.Join(folders, "/")
+		if c := strings.TrimSpace(strings.Split(o.Category, ",")[0]); c != "" {
+			category = strings.Trim(c, "/")
+		}
+		subs = append(subs, Subscription{
+			Title:    title,
+			URL:      strings.TrimSpace(o.XMLURL),
+			HTMLURL:  strings.TrimSpace(o.HTMLURL),
+			Category: category,
+		})
+	}
+	return subs
+}
+
+// RenderOPML encodes a list of feeds as an OPML 2.0 document, filing each feed in a folder named for its category.
+func RenderOPML(title string, subs []Subscription) ([]byte, error) {
+	document := OPMLDocument{
+		Version: "2.0",
+		Head:    opmlHead{Title: title, DateCreated: time.Now().UTC().Format(time.RFC1123Z)},
+	}
+	folders := make(map[string]*Outline)
+	names := make([]string, 0)
+	for _, s := range subs {
+		feed := Outline{Text: s.Title, Title: s.Title, Type: "rss", XMLURL: s.URL, HTMLURL: s.HTMLURL}
+		if s.Category == "" {
+			document.Body.Outlines = append(document.Body.Outlines, feed)
+			continue
+		}
+		feed.Category = "/" + s.Category
+		folder, ok := folders[s.Category]
+		if !ok {
+			folder = &Outline{Text: s.Category, Title: s.Category}
+			folders[s.Category] = folder
+			names = append(names, s.Category)
+		}
+		folder.Outlines = append(folder.Outlines, feed)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		document.Body.Outlines = append(document.Body.Outlines, *folders[name])
+	}
+	out, err := xml.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
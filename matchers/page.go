@@ -0,0 +1,261 @@
+package matchers
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+type (
+	// Page defines the preview metadata pulled out of an HTML document's OpenGraph and Twitter card tags, falling back
+	// to the plain <title> and description meta tags when a site doesn't provide them.
+	Page struct {
+		URL         string
+		Title       string
+		Description string
+		SiteName    string
+		Image       string
+	}
+)
+
+const (
+	// maxPageSize is the most of a page that's read looking for metadata. The tags live in the <head>, so there's no
+	// need to download an entire article.
+	maxPageSize = 512 * 1024
+	// pageTimeout is how long to wait for a page before giving up on it.
+	pageTimeout = 10 * time.Second
+)
+
+// pageClient is used for page requests so that a slow site can't hold up a handler forever.
+var pageClient = PublicClient(pageTimeout)
+
+// PublicClient creates an HTTP client for fetching links that anyone in a group can post, with the given timeout. It
+// only connects to public addresses, wherever a link redirects, and doesn't go through a proxy that might not.
+func PublicClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:         (&net.Dialer{Timeout: timeout, Control: dialPublic}).DialContext,
+			TLSHandshakeTimeout: timeout,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= 10 {
+				return errors.New("stopped after 10 redirects")
+			}
+			return checkPublic(req.URL)
+		},
+	}
+}
+
+// sharedNetwork is the carrier-grade NAT range, which net.IP doesn't count as private.
+var sharedNetwork = &net.IPNet{IP: net.IPv4(100, 64, 0, 0), Mask: net.CIDRMask(10, 32)}
+
+// isPublic reports whether ip is an address on the public internet, rather than a loopback, link-local, private or
+// otherwise special one.
+func isPublic(ip net.IP) bool {
+	return !(ip.IsUnspecified() || ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() ||
+		ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || sharedNetwork.Contains(ip) ||
+		ip.Equal(net.IPv4bcast))
+}
+
+// checkPublic resolves a link's host and refuses it unless it's http or https and every address it has is public. The
+// dialer checks the address actually connected to as well, since a name can resolve differently the second time.
+func checkPublic(link *url.URL) error {
+	if link.Scheme != "http" && link.Scheme != "https" {
+		return fmt.Errorf("Not a web page: %s", link)
+	}
+	host := link.Hostname()
+	ips, err := net.LookupIP(host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range ips {
+		if !isPublic(ip) {
+			return fmt.Errorf("Not a public address: %s", host)
+		}
+	}
+	return nil
+}
+
+// dialPublic is a net.Dialer Control hook that refuses connections to addresses that aren't public.
+func dialPublic(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublic(ip) {
+		return fmt.Errorf("Not a public address: %s", host)
+	}
+	return nil
+}
+
+// RetrievePage performs a HTTP Get request for an HTML page and extracts its preview metadata. Only pages at public
+// addresses are retrieved, wherever they redirect.
+func RetrievePage(link string) (*Page, error) {
+	if link == "" {
+		return nil, errors.New("No page uri provided")
+	}
+	req, err := http.NewRequest("GET", link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkPublic(req.URL); err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	req.Header.Set("User-Agent", DefaultUserAgent)
+	resp, err := pageClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponse(resp)
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("HTTP Response Error %d\n", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "html") {
+		return nil, fmt.Errorf("Not an HTML page: %s", ct)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxPageSize))
+	if err != nil {
+		return nil, err
+	}
+	page := ParsePage(string(body))
+	page.URL = resp.Request.URL.String()
+	// Some sites give a relative image path, so resolve it against wherever the page ended up.
+	if page.Image != "" {
+		if img, err := resp.Request.URL.Parse(page.Image); err == nil {
+			page.Image = img.String()
+		}
+	}
+	return page, nil
+}
+
+// ParsePage extracts preview metadata from an HTML document. OpenGraph properties win over Twitter card properties,
+// which win over the document's <title> and description.
+func ParsePage(doc string) *Page {
+	var page Page
+	props := make(map[string]string)
+	lower := asciiLower(doc)
+	for i := 0; i < len(lower); {
+		start := strings.Index(lower[i:], "<meta")
+		if start < 0 {
+			break
+		}
+		start += i
+		end := strings.Index(lower[start:], ">")
+		if end < 0 {
+			break
+		}
+		end += start
+		attrs := parseAttributes(doc[start+len("<meta") : end])
+		key := attrs["property"]
+		if key == "" {
+			key = attrs["name"]
+		}
+		key = strings.ToLower(key)
+		if _, ok := props[key]; key != "" && !ok {
+			props[key] = strings.TrimSpace(html.UnescapeString(attrs["content"]))
+		}
+		i = end
+	}
+	if start := strings.Index(lower, "<title"); start >= 0 {
+		if open := strings.Index(lower[start:], ">"); open >= 0 {
+			open += start + 1
+			if end := strings.Index(lower[open:], "</title"); end >= 0 {
+				props["title"] = strings.TrimSpace(html.UnescapeString(doc[open : open+end]))
+			}
+		}
+	}
+	page.Title = first(props, "og:title", "twitter:title", "title")
+	page.Description = first(props, "og:description", "twitter:description", "description")
+	page.SiteName = first(props, "og:site_name", "application-name")
+	page.Image = first(props, "og:image:secure_url", "og:image", "twitter:image", "twitter:image:src")
+	page.Title = strings.Join(strings.Fields(page.Title), " ")
+	page.Description = strings.Join(strings.Fields(page.Description), " ")
+	return &page
+}
+
+// first returns the first non-empty value found for the given keys.
+func first(props map[string]string, keys ...string) string {
+	for _, k := range keys {
+		if v := props[k]; v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseAttributes reads the name="value" pairs from the inside of an HTML tag. Attribute names are lowercased, and
+// values may be double quoted, single quoted, or bare.
+func parseAttributes(tag string) map[string]string {
+	attrs := make(map[string]string)
+	i := 0
+	for i < len(tag) {
+		for i < len(tag) && (isSpace(tag[i]) || tag[i] == '/') {
+			i++
+		}
+		nameStart := i
+		for i < len(tag) && !isSpace(tag[i]) && tag[i] != '=' && tag[i] != '/' {
+			i++
+		}
+		name := strings.ToLower(tag[nameStart:i])
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		if i >= len(tag) || tag[i] != '=' {
+			if name != "" {
+				attrs[name] = ""
+			}
+			continue
+		}
+		i++
+		for i < len(tag) && isSpace(tag[i]) {
+			i++
+		}
+		var value string
+		if i < len(tag) && (tag[i] == '"' || tag[i] == '\'') {
+			quote := tag[i]
+			i++
+			valueStart := i
+			for i < len(tag) && tag[i] != quote {
+				i++
+			}
+			value = tag[valueStart:i]
+			i++
+		} else {
+			valueStart := i
+			for i < len(tag) && !isSpace(tag[i]) {
+				i++
+			}
+			value = tag[valueStart:i]
+		}
+		if name != "" {
+			attrs[name] = value
+		}
+	}
+	return attrs
+}
+
+// asciiLower lowercases only the ASCII letters in s, so that byte offsets into the result line up with s.
+func asciiLower(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if 'A' <= c && c <= 'Z' {
+			b[i] = c + 'a' - 'A'
+		}
+	}
+	return string(b)
+}
+
+// isSpace reports whether b is HTML whitespace.
+func isSpace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\n' || b == '\r' || b == '\f'
+}
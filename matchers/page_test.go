@@ -0,0 +1,61 @@
+package matchers
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestIsPublic(t *testing.T) {
+	for _, test := range []struct {
+		ip   string
+		want bool
+	}{
+		{"93.184.216.34", true},
+		{"2606:2800:220:1:248:1893:25c8:1946", true},
+		{"127.0.0.1", false},
+		{"::1", false},
+		{"10.1.2.3", false},
+		{"172.16.0.1", false},
+		{"192.168.1.1", false},
+		{"169.254.169.254", false},
+		{"100.64.0.1", false},
+		{"0.0.0.0", false},
+		{"::", false},
+		{"fe80::1", false},
+		{"fd00::1", false},
+		{"::ffff:127.0.0.1", false},
+		{"224.0.0.1", false},
+		{"255.255.255.255", false},
+	} {
+		if got := isPublic(net.ParseIP(test.ip)); got != test.want {
+			t.Errorf("isPublic(%s) = %v, want %v", test.ip, got, test.want)
+		}
+	}
+}
+
+func TestRetrievePageRefusesPrivate(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("a loopback page was requested")
+	}))
+	defer server.Close()
+	for _, link := range []string{server.URL, "http://localhost/", "http://[::1]/", "file:///etc/passwd"} {
+		if _, err := RetrievePage(link); err == nil {
+			t.Errorf("RetrievePage(%s) succeeded, want an error", link)
+		}
+	}
+}
+
+func TestPageClientRefusesPrivate(t *testing.T) {
+	req, _ := http.NewRequest("GET", "http://169.254.169.254/latest/meta-data/", nil)
+	if err := pageClient.CheckRedirect(req, nil); err == nil {
+		t.Error("redirect to a link-local address was allowed")
+	}
+	if err := dialPublic("tcp", "127.0.0.1:80", nil); err == nil {
+		t.Error("dial to a loopback address was allowed")
+	}
+	if err := dialPublic("tcp", "93.184.216.34:443", nil); err != nil {
+		t.Errorf("dial to a public address was refused: %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package matchers
+
+import (
+	"encoding/xml"
+	"strings"
+)
+
+type (
+	// rdfItem defines the fields associated with the item tag
+	// in the rdf document.
+	rdfItem struct {
+		About       string   `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+		Title       string   `xml:"http://purl.org/rss/1.0/ title"`
+		Link        string   `xml:"http://purl.org/rss/1.0/ link"`
+		Description string   `xml:"http://purl.org/rss/1.0/ description"`
+		Date        string   `xml:"http://purl.org/dc/elements/1.1/ date"`
+		Creators    []string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		Subjects    []string `xml:"http://purl.org/dc/elements/1.1/ subject"`
+	}
+
+	// rdfChannel defines the fields associated with the channel tag
+	// in the rdf document.
+	rdfChannel struct {
+		Title       string `xml:"http://purl.org/rss/1.0/ title"`
+		Link        string `xml:"http://purl.org/rss/1.0/ link"`
+		Description string `xml:"http://purl.org/rss/1.0/ description"`
+		Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	}
+
+	// rdfDocument defines the fields associated with the rdf document. Unlike RSS 2.0, the items sit beside the
+	// channel rather than inside it.
+	rdfDocument struct {
+		XMLName xml.Name   `xml:"RDF"`
+		Channel rdfChannel `xml:"http://purl.org/rss/1.0/ channel"`
+		Items   []rdfItem  `xml:"http://purl.org/rss/1.0/ item"`
+	}
+)
+
+// parseRDF decodes an RSS 1.0 (RDF) document into a Feed.
+func parseRDF(data []byte) (*Feed, error) {
+	var document rdfDocument
+	err := newDecoder(data).Decode(&document)
+	if err != nil {
+		return nil, err
+	}
+	feed := &Feed{
+		Format:      FormatRDF,
+		Title:       strings.TrimSpace(document.Channel.Title),
+		Link:        strings.TrimSpace(document.Channel.Link),
+		Description: strings.TrimSpace(document.Channel.Description),
+		Updated:     parseTimeOrZero(document.Channel.Date),
+		Items:       make([]FeedItem, 0, len(document.Items)),
+	}
+	for _, item := range document.Items {
+		fi := FeedItem{
+			ID:          strings.TrimSpace(item.About),
+			Title:       strings.TrimSpace(item.Title),
+			Link:        strings.TrimSpace(item.Link),
+			Description: strings.TrimSpace(item.Description),
+			Published:   parseTimeOrZero(item.Date),
+			Authors:     trimAll(item.Creators),
+			Categories:  trimAll(item.Subjects),
+		}
+		if fi.ID == "" {
+			fi.ID = fi.Link
+		}
+		feed.Items = append(feed.Items, fi)
+	}
+	return feed, nil
+}
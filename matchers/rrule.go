@@ -0,0 +1,224 @@
+package matchers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type (
+	// Recurrence is an event's RRULE. FREQ values of DAILY, WEEKLY, MONTHLY and YEARLY are understood, along with
+	// INTERVAL, COUNT, UNTIL, BYDAY, BYMONTHDAY and BYMONTH.
+	Recurrence struct {
+		Freq     string
+		Interval int
+		// Count is the number of occurrences, counting the first; zero means no limit
+		Count int
+		// Until is the last time an occurrence can start; zero means no limit
+		Until      time.Time
+		ByDay      []RecurrenceDay
+		ByMonthDay []int
+		ByMonth    []time.Month
+	}
+
+	// RecurrenceDay is a BYDAY entry like "FR", "1MO" (the first Monday) or "-1SU" (the last Sunday).
+	RecurrenceDay struct {
+		Weekday time.Weekday
+		// N picks one of the matching weekdays in the month, counting from the end when negative; zero means all
+		N int
+	}
+)
+
+// maxPeriods bounds how many days, weeks, months or years are stepped through when expanding a recurrence, so a rule
+// that never matches can't loop forever.
+const maxPeriods = 20000
+
+// icsWeekdays maps RRULE weekday codes to weekdays.
+var icsWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday,
+}
+
+// parseRecurrence reads an RRULE value like "FREQ=WEEKLY;BYDAY=TU,TH;COUNT=10". Times in UNTIL without a zone are read
+// in loc. Rules with a frequency that isn't understood are nil.
+func parseRecurrence(value string, loc *time.Location) *Recurrence {
+	r := Recurrence{Interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		eq := strings.IndexByte(part, '=')
+		if eq < 0 {
+			continue
+		}
+		key, val := strings.ToUpper(part[:eq]), part[eq+1:]
+		switch key {
+		case "FREQ":
+			r.Freq = strings.ToUpper(val)
+		case "INTERVAL":
+			if n, err := strconv.Atoi(val); err == nil && n > 0 {
+				r.Interval = n
+			}
+		case "COUNT":
+			r.Count, _ = strconv.Atoi(val)
+		case "UNTIL":
+			r.Until, _, _ = icsDate(val, loc)
+		case "BYDAY":
+			for _, day := range strings.Split(strings.ToUpper(val), ",") {
+				if len(day) < 2 {
+					continue
+				}
+				weekday, ok := icsWeekdays[day[len(day)-2:]]
+				if !ok {
+					continue
+				}
+				n, _ := strconv.Atoi(day[:len(day)-2])
+				r.ByDay = append(r.ByDay, RecurrenceDay{Weekday: weekday, N: n})
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				if n, err := strconv.Atoi(day); err == nil && n != 0 {
+					r.ByMonthDay = append(r.ByMonthDay, n)
+				}
+			}
+		case "BYMONTH":
+			for _, month := range strings.Split(val, ",") {
+				if n, err := strconv.Atoi(month); err == nil && n >= 1 && n <= 12 {
+					r.ByMonth = append(r.ByMonth, time.Month(n))
+				}
+			}
+		}
+	}
+	switch r.Freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		return &r
+	}
+	return nil
+}
+
+// starts returns the start times of the occurrences of a rule beginning at start, up to but not including before.
+// The first occurrence is always start itself.
+func (r *Recurrence) starts(start, before time.Time) []time.Time {
+	times := []time.Time{start}
+	count := 1
+	for period := 0; period < maxPeriods; period++ {
+		for _, t := range r.candidates(start, period) {
+			if !t.After(start) {
+				continue
+			}
+			if r.Count > 0 && count >= r.Count || !r.Until.IsZero() && t.After(r.Until) || !t.Before(before) {
+				return times
+			}
+			times = append(times, t)
+			count++
+		}
+	}
+	return times
+}
+
+// candidates returns the times in the nth period after start that the rule picks, in order.
+func (r *Recurrence) candidates(start time.Time, n int) []time.Time {
+	y, m, d := start.Date()
+	hour, min, sec := start.Clock()
+	loc := start.Location()
+	at := func(year int, month time.Month, day int) time.Time {
+		return time.Date(year, month, day, hour, min, sec, 0, loc)
+	}
+	var days []time.Time
+	switch r.Freq {
+	case "DAILY":
+		day := at(y, m, d+n*r.Interval)
+		if r.monthAllowed(day.Month()) && r.weekdayAllowed(day.Weekday()) {
+			days = append(days, day)
+		}
+	case "WEEKLY":
+		// Weeks start on Monday.
+		monday := at(y, m, d-(int(start.Weekday())+6)%7+7*n*r.Interval)
+		if len(r.ByDay) == 0 {
+			days = append(days, monday.AddDate(0, 0, (int(start.Weekday())+6)%7))
+			break
+		}
+		for i := 0; i < 7; i++ {
+			if day := monday.AddDate(0, 0, i); r.weekdayAllowed(day.Weekday()) {
+				days = append(days, day)
+			}
+		}
+	case "MONTHLY":
+		first := at(y, m+time.Month(n*r.Interval), 1)
+		if r.monthAllowed(first.Month()) {
+			days = r.inMonth(first, d)
+		}
+	case "YEARLY":
+		months := r.ByMonth
+		if len(months) == 0 {
+			months = []time.Month{m}
+		}
+		for _, month := range months {
+			days = append(days, r.inMonth(at(y+n*r.Interval, month, 1), d)...)
+		}
+	}
+	sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+	return days
+}
+
+// inMonth returns the days in the month starting at first that the rule's BYMONTHDAY or BYDAY picks, or the given day
+// of the month when it has neither. Days the month doesn't have are skipped.
+func (r *Recurrence) inMonth(first time.Time, day int) []time.Time {
+	length := first.AddDate(0, 1, -1).Day()
+	var days []time.Time
+	switch {
+	case len(r.ByMonthDay) > 0:
+		for _, d := range r.ByMonthDay {
+			if d < 0 {
+				d = length + d + 1
+			}
+			if d >= 1 && d <= length {
+				days = append(days, first.AddDate(0, 0, d-1))
+			}
+		}
+	case len(r.ByDay) > 0:
+		for _, by := range r.ByDay {
+			var matches []time.Time
+			for d := 0; d < length; d++ {
+				if t := first.AddDate(0, 0, d); t.Weekday() == by.Weekday {
+					matches = append(matches, t)
+				}
+			}
+			switch {
+			case by.N == 0:
+				days = append(days, matches...)
+			case by.N > 0 && by.N <= len(matches):
+				days = append(days, matches[by.N-1])
+			case by.N < 0 && -by.N <= len(matches):
+				days = append(days, matches[len(matches)+by.N])
+			}
+		}
+	case day <= length:
+		days = append(days, first.AddDate(0, 0, day-1))
+	}
+	return days
+}
+
+// weekdayAllowed reports whether BYDAY allows a weekday, ignoring any ordinals.
+func (r *Recurrence) weekdayAllowed(weekday time.Weekday) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, by := range r.ByDay {
+		if by.Weekday == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+// monthAllowed reports whether BYMONTH allows a month.
+func (r *Recurrence) monthAllowed(month time.Month) bool {
+	if len(r.ByMonth) == 0 {
+		return true
+	}
+	for _, m := range r.ByMonth {
+		if m == month {
+			return true
+		}
+	}
+	return false
+}
@@ -2,22 +2,60 @@ package matchers
 
 import (
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+// Namespaces of the RSS extensions that are read from items.
+const (
+	dcNamespace      = "http://purl.org/dc/elements/1.1/"
+	contentNamespace = "http://purl.org/rss/1.0/modules/content/"
+	mediaNamespace   = "http://search.yahoo.com/mrss/"
+	georssNamespace  = "http://www.georss.org/georss"
 )
 
 type (
 	// Item defines the fields associated with the item tag
 	// in the rss document.
 	Item struct {
-		XMLName     xml.Name `xml:"item"`
-		PubDate     string   `xml:"pubDate"`
-		Title       string   `xml:"title"`
-		Description string   `xml:"description"`
-		Link        string   `xml:"link"`
-		GUID        string   `xml:"guid"`
-		GeoRssPoint string   `xml:"georss:point"`
+		XMLName     xml.Name       `xml:"item"`
+		PubDate     string         `xml:"pubDate"`
+		Title       string         `xml:"title"`
+		Description string         `xml:"description"`
+		Link        string         `xml:"link"`
+		GUID        string         `xml:"guid"`
+		GeoRssPoint string         `xml:"http://www.georss.org/georss point"`
+		Author      string         `xml:"author"`
+		Categories  []string       `xml:"category"`
+		Enclosures  []RSSEnclosure `xml:"enclosure"`
+		// Creator is the item's dc:creator, which many feeds use in place of an author's email address
+		Creator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+		// DCDate is the item's dc:date, used by feeds that don't give a pubDate
+		DCDate string `xml:"http://purl.org/dc/elements/1.1/ date"`
+		// ContentEncoded is the item's full content:encoded HTML
+		ContentEncoded string         `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+		Media          []MediaContent `xml:"http://search.yahoo.com/mrss/ content"`
+		Thumbnail      string         `xml:"-"`
+	}
+
+	// MediaContent defines the fields associated with the media:content tag
+	// in the rss document.
+	MediaContent struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Medium string `xml:"medium,attr"`
+		Width  int    `xml:"width,attr"`
+		Height int    `xml:"height,attr"`
+	}
+
+	// RSSEnclosure defines the fields associated with the enclosure tag
+	// in the rss document.
+	RSSEnclosure struct {
+		URL    string `xml:"url,attr"`
+		Type   string `xml:"type,attr"`
+		Length string `xml:"length,attr"`
 	}
 
 	// image defines the fields associated with the image tag
@@ -53,31 +91,228 @@ type (
 	}
 )
 
-// Retrieve performs a HTTP Get request for the rss feed and decodes the results.
-func Retrieve(feed string) (*RSSDocument, error) {
-	if feed == "" {
-		return nil, errors.New("No rss feed uri provided")
+// Published parses the item's raw pubDate into a time.Time.
+func (item Item) Published() (time.Time, error) {
+	return ParseTime(item.PubDate)
+}
+
+// UnmarshalXML decodes an item, matching elements by namespace as well as name. encoding/xml matches an untagged
+// name in any namespace, so without this a <media:title> or <atom:link> would overwrite the item's own title or link.
+func (item *Item) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	item.XMLName = start.Name
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			err = item.decodeChild(d, t, start.Name.Space)
+			if err != nil {
+				return err
+			}
+		}
 	}
+}
 
-	// Retrieve the rss feed document from the web.
-	resp, err := http.Get(feed)
-	if err != nil {
-		return nil, err
+// decodeChild decodes one of an item's child elements into the matching field, skipping anything unrecognized.
+func (item *Item) decodeChild(d *xml.Decoder, child xml.StartElement, space string) error {
+	var text string
+	var err error
+	switch child.Name.Space {
+	case "", space:
+		switch child.Name.Local {
+		case "pubDate":
+			item.PubDate, err = textOf(d, child)
+		case "title":
+			item.Title, err = textOf(d, child)
+		case "description":
+			item.Description, err = textOf(d, child)
+		case "link":
+			item.Link, err = textOf(d, child)
+		case "guid":
+			item.GUID, err = textOf(d, child)
+		case "author":
+			item.Author, err = textOf(d, child)
+		case "category":
+			text, err = textOf(d, child)
+			item.Categories = append(item.Categories, text)
+		case "enclosure":
+			item.Enclosures = append(item.Enclosures, RSSEnclosure{
+				URL:    attr(child, "url"),
+				Type:   attr(child, "type"),
+				Length: attr(child, "length"),
+			})
+			err = d.Skip()
+		default:
+			err = d.Skip()
+		}
+	case dcNamespace:
+		switch child.Name.Local {
+		case "creator":
+			item.Creator, err = textOf(d, child)
+		case "date":
+			item.DCDate, err = textOf(d, child)
+		default:
+			err = d.Skip()
+		}
+	case contentNamespace:
+		if child.Name.Local == "encoded" {
+			item.ContentEncoded, err = textOf(d, child)
+		} else {
+			err = d.Skip()
+		}
+	case georssNamespace:
+		if child.Name.Local == "point" {
+			item.GeoRssPoint, err = textOf(d, child)
+		} else {
+			err = d.Skip()
+		}
+	case mediaNamespace:
+		err = item.decodeMedia(d, child)
+	default:
+		err = d.Skip()
 	}
+	return err
+}
 
-	// Close the response once we return from the function.
-	defer closeResponse(resp)
+// decodeMedia reads the Media RSS elements of an item, including those wrapped in a <media:group>.
+func (item *Item) decodeMedia(d *xml.Decoder, child xml.StartElement) error {
+	switch child.Name.Local {
+	case "content":
+		width, _ := strconv.Atoi(attr(child, "width"))
+		height, _ := strconv.Atoi(attr(child, "height"))
+		item.Media = append(item.Media, MediaContent{
+			URL:    attr(child, "url"),
+			Type:   attr(child, "type"),
+			Medium: attr(child, "medium"),
+			Width:  width,
+			Height: height,
+		})
+		// A media:content can hold its own thumbnail.
+		return item.decodeMediaChildren(d)
+	case "thumbnail":
+		if item.Thumbnail == "" {
+			item.Thumbnail = attr(child, "url")
+		}
+		return d.Skip()
+	case "group":
+		return item.decodeMediaChildren(d)
+	}
+	return d.Skip()
+}
 
-	// Check the status code for a 200 so we know we have received a
-	// proper response.
-	if resp.StatusCode != 200 {
-		return nil, fmt.Errorf("HTTP Response Error %d\n", resp.StatusCode)
+// decodeMediaChildren reads the Media RSS elements nested in the current element.
+func (item *Item) decodeMediaChildren(d *xml.Decoder) error {
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if t.Name.Space == mediaNamespace {
+				err = item.decodeMedia(d, t)
+			} else {
+				err = d.Skip()
+			}
+			if err != nil {
+				return err
+			}
+		}
 	}
+}
+
+// UnmarshalXML decodes a channel, matching elements by namespace as well as name so that extension elements like
+// <atom:link> don't overwrite the channel's own.
+func (ch *channel) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	ch.XMLName = start.Name
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.EndElement:
+			return nil
+		case xml.StartElement:
+			if t.Name.Space != "" && t.Name.Space != start.Name.Space {
+				err = d.Skip()
+			} else {
+				err = ch.decodeChild(d, t)
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// decodeChild decodes one of a channel's child elements into the matching field, skipping anything unrecognized.
+func (ch *channel) decodeChild(d *xml.Decoder, child xml.StartElement) error {
+	var err error
+	switch child.Name.Local {
+	case "title":
+		ch.Title, err = textOf(d, child)
+	case "description":
+		ch.Description, err = textOf(d, child)
+	case "link":
+		ch.Link, err = textOf(d, child)
+	case "pubDate":
+		ch.PubDate, err = textOf(d, child)
+	case "lastBuildDate":
+		ch.LastBuildDate, err = textOf(d, child)
+	case "ttl":
+		ch.TTL, err = textOf(d, child)
+	case "language":
+		ch.Language, err = textOf(d, child)
+	case "managingEditor":
+		ch.ManagingEditor, err = textOf(d, child)
+	case "webMaster":
+		ch.WebMaster, err = textOf(d, child)
+	case "image":
+		err = d.DecodeElement(&ch.Image, &child)
+	case "item":
+		var item Item
+		err = d.DecodeElement(&item, &child)
+		ch.Item = append(ch.Item, item)
+	default:
+		err = d.Skip()
+	}
+	return err
+}
+
+// textOf reads the character data of an element that has just been started.
+func textOf(d *xml.Decoder, start xml.StartElement) (string, error) {
+	var text string
+	err := d.DecodeElement(&text, &start)
+	return text, err
+}
+
+// attr returns the value of an element's attribute, ignoring its namespace.
+func attr(start xml.StartElement, name string) string {
+	for _, a := range start.Attr {
+		if a.Name.Local == name {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// Retrieve performs a HTTP Get request for the rss feed and decodes the results, using DefaultClient.
+func Retrieve(feed string) (*RSSDocument, error) {
+	return DefaultClient.Retrieve(feed)
+}
 
-	// Decode the rss feed document into our struct type.
-	// We don't need to check for errors, the caller can do this.
+// decodeRSS decodes an rss feed document into our struct type.
+// We don't need to check for errors, the caller can do this.
+func decodeRSS(data []byte) (*RSSDocument, error) {
 	var document RSSDocument
-	err = xml.NewDecoder(resp.Body).Decode(&document)
+	err := newDecoder(data).Decode(&document)
 	return &document, err
 }
 
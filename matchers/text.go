@@ -0,0 +1,142 @@
+package matchers
+
+import (
+	"bytes"
+	"html"
+	"strings"
+	"unicode"
+)
+
+// blockTags are the elements that start a new line of text.
+var blockTags = map[string]bool{
+	"p": true, "br": true, "div": true, "tr": true, "table": true, "ul": true, "ol": true, "dl": true, "dt": true,
+	"dd": true, "h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true, "blockquote": true,
+	"pre": true, "hr": true, "section": true, "article": true, "header": true, "footer": true, "figure": true,
+	"figcaption": true, "aside": true, "nav": true, "center": true,
+}
+
+// skippedTags are the elements whose content is never shown.
+var skippedTags = map[string]bool{
+	"script": true, "style": true, "head": true, "noscript": true, "template": true, "iframe": true, "object": true,
+	"svg": true,
+}
+
+// PlainText converts an HTML fragment, like a feed item's description, into compact plain text. Entities are decoded,
+// scripts and styles are dropped along with their content, list items become "•" bullets on their own lines, table
+// cells are separated by spaces, and runs of whitespace and blank lines are collapsed.
+func PlainText(fragment string) string {
+	// Some feeds escape their HTML a second time, so it arrives here as "&lt;p&gt;...".
+	if !strings.Contains(fragment, "<") && strings.Contains(fragment, "&lt;") {
+		fragment = html.UnescapeString(fragment)
+	}
+	var out bytes.Buffer
+	lower := asciiLower(fragment)
+	i := 0
+	for i < len(fragment) {
+		lt := strings.IndexByte(fragment[i:], '<')
+		if lt < 0 {
+			out.WriteString(html.UnescapeString(fragment[i:]))
+			break
+		}
+		out.WriteString(html.UnescapeString(fragment[i : i+lt]))
+		i += lt
+		if strings.HasPrefix(fragment[i:], "<!--") {
+			end := strings.Index(fragment[i:], "-->")
+			if end < 0 {
+				break
+			}
+			i += end + len("-->")
+			continue
+		}
+		gt := strings.IndexByte(fragment[i:], '>')
+		if gt < 0 {
+			// A lone "<" is just text.
+			out.WriteString(fragment[i:])
+			break
+		}
+		inside := lower[i+1 : i+gt]
+		name, closing := tagName(inside)
+		i += gt + 1
+		switch {
+		case name == "":
+			out.WriteString(fragment[i-gt-1 : i])
+		case skippedTags[name] && !closing && !strings.HasSuffix(inside, "/"):
+			// Only the tag itself is dropped when it's never closed, so that a stray one doesn't swallow the rest.
+			end := strings.Index(lower[i:], "</"+name)
+			if end < 0 {
+				break
+			}
+			i += end
+			if gt := strings.IndexByte(fragment[i:], '>'); gt >= 0 {
+				i += gt + 1
+			} else {
+				i = len(fragment)
+			}
+		case name == "li" && !closing:
+			out.WriteString("\n• ")
+		case blockTags[name] || name == "li":
+			out.WriteString("\n")
+		case name == "td" || name == "th":
+			out.WriteString(" ")
+		}
+	}
+	return compact(out.String())
+}
+
+// tagName returns the lowercased name of the tag whose inside is given, and whether it's a closing tag. The name is
+// blank when the inside doesn't look like a tag at all, like the "< " in "1 < 2".
+func tagName(inside string) (string, bool) {
+	closing := strings.HasPrefix(inside, "/")
+	inside = strings.TrimPrefix(inside, "/")
+	end := 0
+	for end < len(inside) && (inside[end] >= 'a' && inside[end] <= 'z' || end > 0 && inside[end] >= '0' && inside[end] <= '9') {
+		end++
+	}
+	if end == 0 && strings.HasPrefix(inside, "!") {
+		// Doctypes and CDATA markers are dropped like any other tag.
+		return "!", closing
+	}
+	return inside[:end], closing
+}
+
+// compact collapses the whitespace in each line, and drops blank lines.
+func compact(text string) string {
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.Join(strings.FieldsFunc(line, isCollapsible), " ")
+		if line != "" && line != "•" {
+			kept = append(kept, line)
+		}
+	}
+	return strings.Join(kept, "\n")
+}
+
+// isCollapsible reports whether r is whitespace within a line, including the non-breaking spaces that &nbsp; leaves.
+func isCollapsible(r rune) bool {
+	return r != '\n' && unicode.IsSpace(r)
+}
+
+// Truncate shortens text to at most max characters, cutting at the last word boundary and ending with an ellipsis.
+// Text that already fits is returned as-is.
+func Truncate(text string, max int) string {
+	runes := []rune(text)
+	if max < 1 || len(runes) <= max {
+		return text
+	}
+	cut := max - 1
+	for i := cut; i > 0; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+	return strings.TrimRightFunc(string(runes[:cut]), func(r rune) bool {
+		return unicode.IsSpace(r) || strings.ContainsRune(",.;:-–—", r)
+	}) + "…"
+}
+
+// Snippet renders an HTML fragment as plain text no longer than max characters.
+func Snippet(fragment string, max int) string {
+	return Truncate(PlainText(fragment), max)
+}
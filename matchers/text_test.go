@@ -0,0 +1,23 @@
+package matchers
+
+import "testing"
+
+func TestPlainText(t *testing.T) {
+	for _, test := range []struct {
+		fragment, want string
+	}{
+		{`<p>Hello <b>there</b> &amp; welcome</p>`, "Hello there & welcome"},
+		{`<ul><li>One</li><li>Two</li></ul>`, "• One\n• Two"},
+		{`Before<script>alert("hi")</script> after`, "Before after"},
+		{`Before<style>p { color: red }</style> after`, "Before after"},
+		// A self-closing or unclosed skipped tag only drops itself.
+		{`<svg viewBox="0 0 1 1"/>Icon label, then the rest`, "Icon label, then the rest"},
+		{`<iframe src="https://example.com/">The rest of the story`, "The rest of the story"},
+		{`<p>One</p><object data="x.swf"><p>Two</p>`, "One\nTwo"},
+		{`Caf&eacute;<!-- note --> open`, "Café open"},
+	} {
+		if got := PlainText(test.fragment); got != test.want {
+			t.Errorf("PlainText(%q) = %q, want %q", test.fragment, got, test.want)
+		}
+	}
+}
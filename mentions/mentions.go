@@ -0,0 +1,123 @@
+/*
+Package mentions builds GroupMe posts that @mention users. GroupMe only highlights and notifies a mention when the post
+carries a "mentions" attachment giving each user's ID along with the position of their "@Name" in the text, so the two
+have to be built together.
+
+Positions are counted the way GroupMe counts them: in UTF-16 code units, so a name or a line before it with emoji or
+other characters outside the Basic Multilingual Plane shifts what follows by two rather than one.
+*/
+package mentions
+
+import (
+	"bytes"
+	"strconv"
+
+	"github.com/sha1sum/golang_groupme_bot/bot"
+)
+
+// User is someone to mention.
+type User struct {
+	ID string
+	// Name is shown after the "@"; it doesn't have to be the user's current nickname, but it reads better if it is
+	Name string
+}
+
+// fallbackName is shown for users whose name isn't known.
+const fallbackName = "someone"
+
+// Builder accumulates the text of a post along with the loci and IDs of the users it mentions. The zero value is an
+// empty post, ready to use.
+type Builder struct {
+	text bytes.Buffer
+	// length is the length of text in UTF-16 code units
+	length  int
+	loci    [][2]int
+	userIDs []int
+}
+
+// New starts a post with the given text.
+func New(text string) *Builder {
+	b := new(Builder)
+	b.WriteString(text)
+	return b
+}
+
+// WriteString appends plain text to the post.
+func (b *Builder) WriteString(s string) {
+	b.text.WriteString(s)
+	b.length += utf16Len(s)
+}
+
+// Mention appends "@Name" for a user. Users whose ID isn't a GroupMe user ID are written out but not mentioned.
+func (b *Builder) Mention(u User) {
+	name := u.Name
+	if name == "" {
+		name = fallbackName
+	}
+	token := "@" + name
+	if id, err := strconv.Atoi(u.ID); err == nil {
+		b.loci = append(b.loci, [2]int{b.length, utf16Len(token)})
+		b.userIDs = append(b.userIDs, id)
+	}
+	b.WriteString(token)
+}
+
+// MentionAll appends "@Name" for each user, separated by spaces. Users are only mentioned once, however many times
+// they're given.
+func (b *Builder) MentionAll(users []User) {
+	seen := make(map[string]bool, len(users))
+	first := true
+	for _, u := range users {
+		if seen[u.ID] {
+			continue
+		}
+		seen[u.ID] = true
+		if !first {
+			b.WriteString(" ")
+		}
+		first = false
+		b.Mention(u)
+	}
+}
+
+// Len returns the length of the post's text in bytes.
+func (b *Builder) Len() int {
+	return b.text.Len()
+}
+
+// Text returns the post's text.
+func (b *Builder) Text() string {
+	return b.text.String()
+}
+
+// Attachments returns the "mentions" attachment for the post, or nothing when it doesn't mention anyone.
+func (b *Builder) Attachments() []bot.Attachment {
+	if len(b.userIDs) == 0 {
+		return nil
+	}
+	return []bot.Attachment{
+		bot.Attachment{
+			Type:    "mentions",
+			Loci:    b.loci,
+			UserIDs: b.userIDs,
+		},
+	}
+}
+
+// Message returns the post as a message ready to send.
+func (b *Builder) Message() *bot.OutgoingMessage {
+	return &bot.OutgoingMessage{Text: b.Text(), Attachments: b.Attachments()}
+}
+
+// utf16Len returns the length of s in UTF-16 code units.
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		if r >= 0x10000 {
+			// Encoded as a surrogate pair.
+			n++
+		}
+		n++
+	}
+	return n
+}
@@ -0,0 +1,77 @@
+package mentions
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/sha1sum/golang_groupme_bot/bot"
+)
+
+func TestMention(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		prefix  string
+		user    User
+		text    string
+		loci    [][2]int
+		userIDs []int
+	}{
+		{"plain", "Hi ", User{ID: "12", Name: "Ann"}, "Hi @Ann", [][2]int{{3, 4}}, []int{12}},
+		{"emoji before", "🎷 Hi ", User{ID: "12", Name: "Ann"}, "🎷 Hi @Ann", [][2]int{{6, 4}}, []int{12}},
+		{"emoji inside", "Hi ", User{ID: "12", Name: "Ann 🎺"}, "Hi @Ann 🎺", [][2]int{{3, 7}}, []int{12}},
+		{"accents count once", "Café ", User{ID: "12", Name: "Zoë"}, "Café @Zoë", [][2]int{{5, 4}}, []int{12}},
+		{"no name", "", User{ID: "12"}, "@someone", [][2]int{{0, 8}}, []int{12}},
+		{"non-numeric ID", "Hi ", User{ID: "system", Name: "GroupMe"}, "Hi @GroupMe", nil, nil},
+	} {
+		b := New(test.prefix)
+		b.Mention(test.user)
+		if got := b.Text(); got != test.text {
+			t.Errorf("%s: text = %q, want %q", test.name, got, test.text)
+		}
+		if !reflect.DeepEqual(b.loci, test.loci) || !reflect.DeepEqual(b.userIDs, test.userIDs) {
+			t.Errorf("%s: loci, user IDs = %v, %v, want %v, %v", test.name, b.loci, b.userIDs, test.loci, test.userIDs)
+		}
+	}
+}
+
+func TestMentionAll(t *testing.T) {
+	b := New("Going: ")
+	b.MentionAll([]User{
+		{ID: "1", Name: "Ann"},
+		{ID: "2", Name: "🎷Bo"},
+		{ID: "1", Name: "Ann again"},
+		{ID: "bot", Name: "Helper"},
+		{ID: "3", Name: "Cy"},
+	})
+	b.WriteString("!")
+	if want := "Going: @Ann @🎷Bo @Helper @Cy!"; b.Text() != want {
+		t.Errorf("text = %q, want %q", b.Text(), want)
+	}
+	want := []bot.Attachment{{
+		Type:    "mentions",
+		Loci:    [][2]int{{7, 4}, {12, 5}, {26, 3}},
+		UserIDs: []int{1, 2, 3},
+	}}
+	if got := b.Attachments(); !reflect.DeepEqual(got, want) {
+		t.Errorf("attachments = %+v, want %+v", got, want)
+	}
+	if m := b.Message(); m.Text != b.Text() || !reflect.DeepEqual(m.Attachments, want) {
+		t.Errorf("message = %+v", m)
+	}
+}
+
+func TestAttachmentsWithoutMentions(t *testing.T) {
+	var empty Builder
+	if got := empty.Attachments(); got != nil {
+		t.Errorf("empty post attachments = %+v, want nil", got)
+	}
+	b := New("Nobody's going to ")
+	b.MentionAll(nil)
+	b.Mention(User{ID: "not-a-user", Name: "Someone"})
+	if got := b.Attachments(); got != nil {
+		t.Errorf("attachments = %+v, want nil", got)
+	}
+	if m := b.Message(); m.Attachments != nil {
+		t.Errorf("message attachments = %+v, want nil", m.Attachments)
+	}
+}